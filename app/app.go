@@ -7,6 +7,8 @@ import (
 	"io"
 	"mime"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/mateusz834/charts/log"
@@ -153,7 +155,11 @@ func sendJSON(w http.ResponseWriter, status int, content any) error {
 }
 
 func sendHTMLFunc(w http.ResponseWriter, status int, f func(w io.Writer) error) error {
-	w.Header().Add("Content-Type", "text/html; charset=utf-8")
+	return sendFunc(w, status, "text/html; charset=utf-8", f)
+}
+
+func sendFunc(w http.ResponseWriter, status int, contentType string, f func(w io.Writer) error) error {
+	w.Header().Add("Content-Type", contentType)
 	w.WriteHeader(status)
 	if err := f(writerErrorWrapper{w}); err != nil {
 		if v, ok := err.(writeError); ok {
@@ -165,9 +171,12 @@ func sendHTMLFunc(w http.ResponseWriter, status int, f func(w io.Writer) error)
 }
 
 type SessionService interface {
-	NewSession(githubUserID uint64) (string, error)
-	IsSessionValid(session string) (uint64, error)
+	NewSession(githubUserID uint64, userAgent, ip string) (string, time.Time, error)
+	IsSessionValid(session string) (service.ValidSession, error)
 	RemoveSession(session string) error
+	GetUserSessions(session string) ([]service.SessionInfo, error)
+	RevokeSession(githubUserID uint64, id int64) error
+	RemoveUserSessions(githubUserID uint64) error
 }
 
 type PublicSharesService interface {
@@ -181,14 +190,28 @@ type PublicSharesService interface {
 type application struct {
 	log log.Logger
 
+	// publicURL is the URL that the server is reachable at, nil when unknown.
+	publicURL *url.URL
+
+	// trustedProxyHeader is the (canonical) name of the header that the client
+	// address is taken from (see clientAddr), empty when not behind a proxy.
+	trustedProxyHeader string
+
 	githubOAuth         OAuth
 	sessionService      SessionService
 	publicSharesService PublicSharesService
+
+	githubUsers githubUserCache
 }
 
-func NewApplication(oauth OAuth, logger log.Logger, session SessionService, publicShares PublicSharesService) *application {
+// NewApplication creates the application, publicURL is the URL (scheme and host) that the
+// server is reachable at, used for absolute links in share pages, it might be nil.
+// The trustedProxyHeader is "X-Forwarded-For", "X-Real-IP" or empty (see clientAddr).
+func NewApplication(oauth OAuth, publicURL *url.URL, trustedProxyHeader string, logger log.Logger, session SessionService, publicShares PublicSharesService) *application {
 	return &application{
 		log:                 logger,
+		publicURL:           publicURL,
+		trustedProxyHeader:  http.CanonicalHeaderKey(trustedProxyHeader),
 		githubOAuth:         oauth,
 		sessionService:      session,
 		publicSharesService: publicShares,
@@ -238,6 +261,10 @@ func (a *application) setRoutes() http.Handler {
 	// 1) { "chart": "base64-encoded-chart" }, it will create a share with a server-generated path.
 	// 2) { "chart": "base64-encoded-chart", custom_path: "path" }, it will create a share with
 	// specified custom_path (if available).
+	// Both forms accept an optional "timezone" (IANA name or UTC offset) of the share creator,
+	// it is used as the default timezone of the share reproducers, and an optional "week_start"
+	// ("sunday" (default) or "monday"), the week start of the calendar that the chart was drawn for,
+	// and optional "frames": ["encoded chart", ...], animation frames shown after the chart.
 	// Returns (200 OK) with JSON:
 	// (on success) { "path": "custom_path or server-generated one" }
 	// (on error) { "error_type": "error_type", error_msg: "error msg" }
@@ -245,6 +272,9 @@ func (a *application) setRoutes() http.Handler {
 	// - "path" -> something is wrong with the custom_path (not available, not allowewd chars)
 	// - "auth" -> authentication error (probaly expired), should ask the user to login again.
 	// - "chart" -> error related to the provided chart encoding.
+	// - "timezone" -> the timezone is not valid.
+	// - "week_start" -> the week start is not valid.
+	// - "frames" -> error related to the provided frames.
 	mux.Handle("/create-share",
 		httpMethod(
 			http.MethodPost,
@@ -281,21 +311,158 @@ func (a *application) setRoutes() http.Handler {
 		requireJSONContentType(a.auth(a.removeChart)),
 	).Handler())
 
+	// Accepts JSON: { "text": "text", "year": 2024, "align": "left|center|right",
+	// "start_week": 0, "spacing": 1, "level": 4, "week_start": "sunday|monday" }
+	// (all except text and year are optional).
+	// Returns (200 OK) with one of following responses:
+	// (on success) { "chart": "encoded chart", "first_week": 0, "width": 10, "clipped": 0 }
+	// "clipped" is the number of pixels of the text that did not fit in the chart.
+	// (on error) { "error_type": "text", "error_msg": "error_msg" }
+	mux.Handle("/text-chart", httpMethod(http.MethodPost,
+		requireJSONContentType(a.textChart),
+	).Handler())
+
+	// Accepts an image (PNG, GIF or JPEG, at most 1MiB) as the request body, and
+	// query params: "year", "levels" ("1" quantizes into intensity levels),
+	// "threshold" (darkness 0-1 at which days are set), "invert" ("1" sets light pixels),
+	// "week_start" ("sunday" (default) or "monday").
+	// Returns (200 OK) with one of following responses:
+	// (on success) { "chart": "encoded chart", "preview": "/render.png?c=encoded chart" }
+	// (on error) { "error_type": "image", "error_msg": "error_msg" }
+	mux.Handle("/import-image", httpMethod(http.MethodPost, a.importImage).Handler())
+
+	// Accepts the output of: git log --format=%aI (at most 4MiB) as the request body, and
+	// query params: "c" (encoded chart) or "share" (share path), "tz" (IANA name or UTC offset,
+	// the intended timezone of commits, defaults to the share timezone or UTC).
+	// Returns (200 OK) with one of following responses:
+	// (on success) { "ok": false, "missing": ["2024-01-01"], "unexpected": ["2024-01-02"],
+	// "wrong_timezone": [{ "commit": "2024-01-03T23:30:00-05:00", "day": "2024-01-03", "intended": "2024-01-04" }],
+	// "outside": 0 }
+	// "missing" are set days without commits, "unexpected" are not set days with commits,
+	// "wrong_timezone" are commits shown on a different day than in the intended timezone and
+	// "outside" is the number of commits outside of the chart.
+	// (on error) { "error_type": "error_type", "error_msg": "error_msg" }
+	// error_type is one of following: "chart", "timezone", "log".
+	mux.Handle("/verify", httpMethod(http.MethodPost, a.verifyHistory).Handler())
+
+	// Accepts JSON: { "op": "op", "charts": ["encoded chart", ...], "days": 0, "weeks": 0, "year": 2024,
+	// "start": "2024-01-01", "week_start": "sunday|monday" } ("week_start" is used by mirror and retarget operations).
+	// op is one of following:
+	// - "union", "intersection", "difference" -> applied from left to right on all charts.
+	// - "invert", "mirror-horizontal", "mirror-vertical" -> exactly one chart.
+	// - "shift" -> exactly one chart, shifted by weeks*7+days days.
+	// - "retarget" -> exactly one chart, moved to the year (preserving the week and weekday of days).
+	// - "retarget-range" -> like "retarget", but moved to the range of "weeks" weeks starting
+	// on "start" (YYYY-MM-DD), e.g. to align the chart with the rolling calendar of github profiles.
+	// Returns (200 OK) with one of following responses:
+	// (on success) { "chart": "encoded chart" }
+	// (on error) { "error_type": "chart", "error_msg": "error_msg" }
+	mux.Handle("/chart-op", httpMethod(http.MethodPost,
+		requireJSONContentType(a.chartOp),
+	).Handler())
+
 	mux.Handle("/get-all-user-shares", httpMethod(http.MethodGet, a.auth(a.getAllUserShares)).Handler())
 	mux.Handle("/logout", httpMethod(http.MethodGet, a.logout).Handler())
 
+	// Returns (200 OK) with one of following responses:
+	// (on success) [{ "id": 1, "created_at": "2024-01-01T12:00:00Z", "last_seen_at": "2024-01-02T12:00:00Z",
+	// "user_agent": "Mozilla/5.0 ...", "ip": "192.0.2.0", "current": true }, ...]
+	// active sessions of the user, the most recently seen first, "ip" is the ip address
+	// (at login, see clientAddr) with the last octet (IPv4) or the last 80 bits (IPv6) zeroed.
+	// With signed sessions only the current session is returned (see service.SignedSessionService.GetUserSessions).
+	// (on error) { "error_type": "auth", "error_msg": "error_msg" }
+	mux.Handle("/get-all-user-sessions", httpMethod(http.MethodGet, a.auth(a.getAllUserSessions)).Handler())
+
+	// Accepts JSON: { "id": 1 } (see /get-all-user-sessions)
+	// Return (200 OK) with one following responses:
+	// (on success) {} (empty json)
+	// (on error) { "error_type": "error_type", "error_msg": "error_msg" }
+	// error_type is one of following:
+	// - "auth" -> authenticated error
+	// - "session" -> the session does not exist.
+	mux.Handle("/revoke-session", httpMethod(http.MethodPost,
+		requireJSONContentType(a.auth(a.revokeSession)),
+	).Handler())
+
+	// Removes all sessions of the user (including the current one).
+	// Return (200 OK) with one following responses:
+	// (on success) {} (empty json)
+	// (on error) { "error_type": "auth", "error_msg": "error_msg" }
+	mux.Handle("/logout-everywhere", httpMethod(http.MethodPost, a.auth(a.logoutEverywhere)).Handler())
+
+	// /s/{path} -> share page, or the share rendered as text for terminals (see shareText)
+	// when the client is curl or prefers text/plain, e.g. curl https://host/s/{path}
+	// /s/{path}.txt -> share rendered as text for terminals (options: see terminalOptions).
+	// /s/{path}.svg -> share rendered as an SVG image (options: see renderOptions).
+	// /s/{path}.png -> share rendered as a PNG image (options: see renderOptions).
+	// /s/{path}.gif -> share followed by its frames, as an animated GIF image (options: see gifOptions).
+	// /s/{path}/reproduce.sh -> git reproducer script (options: see shareReproduceScript).
+	// /s/{path}/fast-import -> git fast-import stream (options: see shareFastImport),
+	// meant to be used like: curl 'https://host/s/{path}/fast-import?email=...' | git fast-import
+	// /s/{path}/stats.json -> share statistics (see the "/stats" route).
+	// Successful responses (including renders) are cached for an hour, errors
+	// (unknown shares, invalid options, too large images) are not cached.
 	mux.Handle("/s/", cacheMiddleware(time.Hour, func(w http.ResponseWriter, r *http.Request) error {
-		return sendHTMLFunc(w, http.StatusOK, func(w io.Writer) error {
-			return templates.Share(w)
-		})
+		path := strings.TrimPrefix(r.URL.Path, "/s/")
+		if path, ok := strings.CutSuffix(path, "/reproduce.sh"); ok {
+			return a.shareReproduceScript(w, r, path)
+		}
+		if path, ok := strings.CutSuffix(path, "/fast-import"); ok {
+			return a.shareFastImport(w, r, path)
+		}
+		if path, ok := strings.CutSuffix(path, "/stats.json"); ok {
+			return a.shareStats(w, r, path)
+		}
+		if path, ok := strings.CutSuffix(path, ".gif"); ok {
+			return a.shareGIF(w, r, path)
+		}
+		for ext, format := range renderFormats {
+			if path, ok := strings.CutSuffix(path, ext); ok {
+				return a.shareRender(w, r, path, format)
+			}
+		}
+		if path, ok := strings.CutSuffix(path, ".txt"); ok {
+			return a.shareText(w, r, path)
+		}
+
+		// The same url serves the page and the text, depending on the client.
+		w.Header().Add("Vary", "Accept, User-Agent")
+		if wantsText(r) {
+			return a.shareText(w, r, path)
+		}
+		return a.sharePage(w, r, path)
 	}).Handler())
 
+	// Render the chart from the "c" query param (encoded chart)
+	// as an image (options: see renderOptions). Successful renders
+	// are cached for a day, errors are not cached.
+	mux.Handle("/render.svg", httpMethod(http.MethodGet, cacheMiddleware(24*time.Hour, a.renderChart(svgFormat))).Handler())
+	mux.Handle("/render.png", httpMethod(http.MethodGet, cacheMiddleware(24*time.Hour, a.renderChart(pngFormat))).Handler())
+
+	// Render the frames from the "c" query param (encoded charts separated
+	// by '.') as an animated GIF image (options: see gifOptions).
+	mux.Handle("/render.gif", httpMethod(http.MethodGet, cacheMiddleware(24*time.Hour, a.renderGIF)).Handler())
+
+	// Returns (200 OK) statistics of the chart from the "c" query param (options: see statsOptions):
+	// { "days": 10, "months": [{ "year": 2024, "month": 1, "days": 3 }, ...],
+	// "weekdays": [0, 1, 2, 3, 4, 0, 0] (sunday first), "longest_streak": { "start": "2024-01-01",
+	// "end": "2024-01-03", "days": 3 }, "longest_gap": { ... }, "weeks_touched": 4,
+	// "today": "2024-02-01", "future_days": ["2024-02-02", ...] }
+	// "longest_streak" and "longest_gap" are null when there is no streak (or gap).
+	mux.Handle("/stats", httpMethod(http.MethodGet, a.chartStats).Handler())
+
 	mux.Handle("/my-shares", cacheMiddleware(time.Hour, func(w http.ResponseWriter, r *http.Request) error {
 		return sendHTMLFunc(w, http.StatusOK, func(w io.Writer) error {
 			return templates.MyShares(w)
 		})
 	}).Handler())
 
+	mux.Handle("/sessions", cacheMiddleware(time.Hour, func(w http.ResponseWriter, r *http.Request) error {
+		return sendHTMLFunc(w, http.StatusOK, func(w io.Writer) error {
+			return templates.Sessions(w)
+		})
+	}).Handler())
+
 	mux.Handle("/", cacheMiddleware(time.Hour, func(w http.ResponseWriter, r *http.Request) error {
 		if r.URL.Path != "/" {
 			http.NotFound(w, r)
@@ -310,9 +477,41 @@ func (a *application) setRoutes() http.Handler {
 	return loggingMiddleware(a.log, mux)
 }
 
+// cacheMiddleware allows caching of successful (2xx) responses for the duration,
+// unless the handler sets the Cache-Control header itself. Errors are not cached.
 func cacheMiddleware(duration time.Duration, handler errHandler) errHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		w.Header().Add("Cache-Control", fmt.Sprintf("max-age=%v", int(duration.Seconds())))
-		return handler(w, r)
+		return handler(&cacheResponseWriter{
+			ResponseWriter: w,
+			cacheControl:   fmt.Sprintf("max-age=%v", int(duration.Seconds())),
+		}, r)
+	}
+}
+
+// cacheResponseWriter adds the Cache-Control header, when a successful status is written.
+type cacheResponseWriter struct {
+	http.ResponseWriter
+	cacheControl string
+	wroteHeader  bool
+}
+
+func (w *cacheResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *cacheResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if status >= 200 && status < 300 && w.Header().Get("Cache-Control") == "" {
+			w.Header().Set("Cache-Control", w.cacheControl)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cacheResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
 	}
+	return w.ResponseWriter.Write(b)
 }
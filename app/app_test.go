@@ -0,0 +1,70 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCacheMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler errHandler
+		want    string
+	}{
+		{
+			name: "ok",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return sendJSON(w, http.StatusOK, struct{}{})
+			},
+			want: "max-age=3600",
+		},
+		{
+			name: "implicit ok",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				w.Write([]byte("ok"))
+				return nil
+			},
+			want: "max-age=3600",
+		},
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				http.NotFound(w, r)
+				return nil
+			},
+		},
+		{
+			name: "bad request",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				w.WriteHeader(http.StatusBadRequest)
+				return nil
+			},
+		},
+		{
+			name: "error",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return errors.New("error")
+			},
+		},
+		{
+			name: "handler cache control",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				w.Header().Set("Cache-Control", "no-cache")
+				return sendJSON(w, http.StatusOK, struct{}{})
+			},
+			want: "no-cache",
+		},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		cacheMiddleware(time.Hour, tt.handler)(w, r)
+		if got := w.Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("%v: Cache-Control = %q, want: %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -114,6 +114,8 @@ document.addEventListener("DOMContentLoaded", () => {
 					body: JSON.stringify({
 						"custom_path": path,
 						chart: this.encodeChart(),
+						timezone: Intl.DateTimeFormat().resolvedOptions().timeZone,
+						week_start: "sunday",
 					})
 				});
 				if (result.status == 200) {
@@ -295,7 +297,8 @@ document.addEventListener("DOMContentLoaded", () => {
 				if (stored != null && stored.includes(date.getTime())) {
 					day.classList.add("clicked");
 				}
-				day.dataset.date = date.toISOString();
+				day.dataset.date = calendarDate(date);
+				day.dataset.time = date.getTime();
 				week.appendChild(day);
 			}
 
@@ -319,11 +322,11 @@ document.addEventListener("DOMContentLoaded", () => {
 			let cmds = "";
 			this.chart.querySelectorAll(".clicked").forEach((node, index) => {
 				if (cmds !== "") {
-					cmds += "\n" + "git commit --date \"" + node.dataset.date + "\" -m \"" + this.commitMsgInput.value + "\""
+					cmds += "\n" + "git commit --date \"" + node.dataset.date + "T12:00:00\" -m " + shellQuote(this.commitMsgInput.value)
 				} else {
-					cmds = "git commit --date \"" + node.dataset.date + "\" -m \"" + this.commitMsgInput.value + "\""
+					cmds = "git commit --date \"" + node.dataset.date + "T12:00:00\" -m " + shellQuote(this.commitMsgInput.value)
 				}
-				clicked[index] = Date.parse(node.dataset.date);
+				clicked[index] = parseInt(node.dataset.time, 10);
 			});
 
 			if (clicked.length === 0) {
@@ -348,7 +351,7 @@ document.addEventListener("DOMContentLoaded", () => {
 			const arr = new Uint8Array(2 + 46);
 			var lastNonZero = 0;
 			this.chart.querySelectorAll(".day").forEach((node, index) => {
-				const date = new Date(Date.parse(node.dataset.date));
+				const date = new Date(parseInt(node.dataset.time, 10));
 				if (index == 0) {
 					const year = date.getFullYear();
 					// Encode year as a 16b inteager in big-endian form.
@@ -374,42 +377,45 @@ document.addEventListener("DOMContentLoaded", () => {
 		},
 
 		decodeChart: function(enc) {
-			if (enc[0] !== '0') {
+			// The editor does not support intensity levels (version "1"),
+			// every day with a non-zero level is treated as clicked.
+			let bitsPerDay;
+			if (enc[0] === '0') {
+				bitsPerDay = 1;
+			} else if (enc[0] === '1') {
+				bitsPerDay = 4;
+			} else {
 				throw new Error("invalid encoding");
 			}
 
 			const arr = urlSafeBase64Decode(enc.substring(1));
 
-			if (arr.length < 2) {
+			if (arr.length < 3 || arr[arr.length-1] === 0) {
 				throw new Error("invalid encoding");
 			}
 
 			const year = (arr[0] << 8) | arr[1]
+			const daysPerByte = 8 / bitsPerDay;
 
 			const clicked = [];
-			let lastZero = false;
 			arr.slice(2).forEach((v, i) => {
-				lastZero = false;
-				if (v == 0) {
-					lastZero = true;
-				}
-
-				for (let bit = 7; bit >= 0; bit--) {
-					if ((v & (1 << bit)) !== 0) {
-						const dayNum = 1 + i*8 + (7 - bit);
-						const date = new Date(year, 0, dayNum, 12);
-						if (date.getFullYear() !== year) {
-							throw new Error("invalid encoding");
-						}
-						clicked.push(date.getTime());
+				for (let j = 0; j < daysPerByte; j++) {
+					const level = (v >> (8 - bitsPerDay*(j+1))) & ((1 << bitsPerDay) - 1);
+					if (level === 0) {
+						continue;
 					}
+					if (level > 4) {
+						throw new Error("invalid encoding");
+					}
+					const dayNum = 1 + i*daysPerByte + j;
+					const date = new Date(year, 0, dayNum, 12);
+					if (date.getFullYear() !== year) {
+						throw new Error("invalid encoding");
+					}
+					clicked.push(date.getTime());
 				}
 			});
 
-			if (lastZero) {
-				throw new Error("invalid encoding");
-			}
-
 			return clicked;
 		}
 	}
@@ -417,10 +423,21 @@ document.addEventListener("DOMContentLoaded", () => {
 	chart.start();
 });
 
+// Returns the calendar date (YYYY-MM-DD) of the date in the local timezone.
+function calendarDate(date) {
+	const pad = (num, len) => String(num).padStart(len, "0");
+	return pad(date.getFullYear(), 4) + "-" + pad(date.getMonth() + 1, 2) + "-" + pad(date.getDate(), 2);
+}
+
 function urlSafeBase64Encode(arr) {
 	return encode(arr).replace(/\//g, '_').replace(/\+/g, '-').replace(/={1,2}$/, '');
 }
 
+// Quotes s as a single POSIX shell word.
+function shellQuote(s) {
+	return "'" + s.replace(/'/g, "'\\''") + "'";
+}
+
 function urlSafeBase64Decode(arr) {
 	let tmp = arr + Array((4 - arr.length % 4) % 4 + 1).join('=');
 	tmp = tmp.replace(/={1,2}$/, '').replace(/_/g, '/').replace(/-/g, '+');
@@ -0,0 +1,61 @@
+document.addEventListener("DOMContentLoaded", async () => {
+	document.getElementById("logout-everywhere").addEventListener("click", async () => {
+		await fetch("/logout-everywhere", { method: "POST" });
+		window.location.href = "/";
+	});
+
+	const result = await fetch("/get-all-user-sessions");
+	if (result.status !== 200) {
+		window.location.href = "/";
+		return;
+	}
+
+	const res = await result.json();
+	if (res["error_type"] !== undefined) {
+		window.location.href = "/";
+		return;
+	}
+
+	for (let i = 0; i < res.length; i++) {
+		const session = document.createElement("div");
+		session.classList.add("session");
+
+		const info = document.createElement("p");
+		const userAgent = document.createElement("strong");
+		userAgent.innerText = res[i]["user_agent"] || "Unknown browser";
+		info.appendChild(userAgent);
+		if (res[i].current) {
+			info.appendChild(document.createTextNode(" (this browser)"));
+		}
+		info.appendChild(document.createElement("br"));
+		info.appendChild(document.createTextNode(
+			"IP: " + (res[i].ip || "unknown") +
+			", logged in: " + new Date(res[i]["created_at"]).toLocaleString() +
+			", last seen: " + new Date(res[i]["last_seen_at"]).toLocaleString()
+		));
+		session.appendChild(info);
+
+		const revokeButton = document.createElement("button");
+		revokeButton.addEventListener("click", async () => {
+			const result = await fetch("/revoke-session", {
+				method: "POST",
+				headers: { "Content-Type": "application/json" },
+				body: JSON.stringify({ id : res[i].id })
+			});
+			if (result.status === 200) {
+				const resJSON = await result.json();
+				if (resJSON["error_type"] === "auth" || res[i].current) {
+					window.location.href = "/";
+					return;
+				}
+				session.remove();
+			}
+		});
+		revokeButton.innerText = res[i].current ? "Log out" : "Revoke";
+		revokeButton.classList.add("button");
+		revokeButton.classList.add("button-red");
+		session.appendChild(revokeButton);
+
+		document.getElementById("sessions").appendChild(session);
+	}
+});
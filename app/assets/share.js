@@ -2,15 +2,19 @@ document.addEventListener("DOMContentLoaded", async () => {
 	const path = document.location.pathname.substring(3);
 	const result = await fetch("/share/" + path);
 	if (result.status !== 200) {
-		document.location.href = "/";
+		const error = document.createElement("p");
+		error.id = "chart-share-error";
+		error.innerText = result.status === 404
+			? "Share not found."
+			: "Failed to load the share (status " + result.status + "), try again later.";
+		document.getElementById("chart-share").append(error);
 		return;
 	}
 
 	const res = await result.json();
 
-	const clicked = decodeChart(res["chart"]);
-	const date = new Date(clicked[0]);
-	const chart = newChart(date.getFullYear(), clicked);
+	const decoded = decodeChart(res["chart"]);
+	const chart = newChart(decoded, weekStartDay(res["week_start"]));
 	chart.id = "chart-share-chart";
 
 
@@ -18,7 +22,12 @@ document.addEventListener("DOMContentLoaded", async () => {
 	chartControls.id = "chart-share-controls";
 
 	const year = document.createElement("div");
-	year.innerText = "Year: " + date.getFullYear();
+	if (decoded.range) {
+		const end = new Date(decoded.start.getFullYear(), decoded.start.getMonth(), decoded.start.getDate() + decoded.days - 1, 12);
+		year.innerText = "Days: " + calendarDate(decoded.start) + " to " + calendarDate(end);
+	} else {
+		year.innerText = "Year: " + decoded.start.getFullYear();
+	}
 	year.id = "chart-share-controls-year";
 	chartControls.append(year);
 
@@ -47,11 +56,14 @@ document.addEventListener("DOMContentLoaded", async () => {
 		chartControls.append(wrapper);
 	}
 
-	const editButton = document.createElement("a");
-	editButton.href = "/?forceedit&s=" + res["chart"];
-	editButton.innerText = "Edit";
-	editButton.classList.add("button", "button-yellow");
-	chartControls.append(editButton);
+	// The editor only supports charts of a single year.
+	if (!decoded.range) {
+		const editButton = document.createElement("a");
+		editButton.href = "/?forceedit&s=" + res["chart"];
+		editButton.innerText = "Edit";
+		editButton.classList.add("button", "button-yellow");
+		chartControls.append(editButton);
+	}
 
 	const gitReproducer = document.createElement("div");
 	gitReproducer.id = "share-git-reproducer";
@@ -81,9 +93,9 @@ document.addEventListener("DOMContentLoaded", async () => {
 		let cmds = "";
 		chart.querySelectorAll(".clicked").forEach((node) => {
 			if (cmds !== "") {
-				cmds += "\n" + "git commit --date \"" + node.dataset.date + "\" -m \"" + commitMessageInput.value + "\""
+				cmds += "\n" + "git commit --date \"" + node.dataset.date + "T12:00:00\" -m " + shellQuote(commitMessageInput.value)
 			} else {
-				cmds = "git commit --date \"" + node.dataset.date + "\" -m \"" + commitMessageInput.value + "\""
+				cmds = "git commit --date \"" + node.dataset.date + "T12:00:00\" -m " + shellQuote(commitMessageInput.value)
 			}
 		});
 		code.innerText = cmds;
@@ -101,8 +113,19 @@ document.addEventListener("DOMContentLoaded", async () => {
 	copyButton.classList.add("button", "button-yellow");
 	copyButton.innerText = "Copy to clipboard";
 
+	const downloadScript = document.createElement("a");
+	downloadScript.classList.add("button", "button-yellow");
+	downloadScript.innerText = "Download script";
+	const downloadScriptUpdate = () => {
+		const params = new URLSearchParams({ message: commitMessageInput.value });
+		downloadScript.href = "/s/" + path + "/reproduce.sh?" + params.toString();
+	};
+	commitMessageInput.addEventListener("input", downloadScriptUpdate);
+	downloadScriptUpdate();
+
 	gitReproControls.append(commitMessageLabel);
 	gitReproControls.append(copyButton);
+	gitReproControls.append(downloadScript);
 
 	gitReproducer.append(gitReproControls);
 
@@ -113,29 +136,41 @@ document.addEventListener("DOMContentLoaded", async () => {
 
 	gitReproducer.append(cmdWrapper);
 
-	document.getElementById("chart-share").append(chartControls, chart, gitReproducer);
+	const shareElements = [chartControls, chart];
+	if (res["frames"].length !== 0) {
+		const animation = document.createElement("img");
+		animation.id = "chart-share-animation";
+		animation.src = "/s/" + path + ".gif";
+		animation.alt = "Animated preview of the chart frames";
+		shareElements.push(animation);
+	}
+	shareElements.push(gitReproducer);
+
+	document.getElementById("chart-share").append(...shareElements);
 });
 
-function newChart(year, stored) {
-	let date = new Date(year, 0, 0, 12);
+// Returns the getDay() index of the first day of the week ("sunday" or "monday").
+function weekStartDay(weekStart) {
+	return weekStart === "monday" ? 1 : 0;
+}
 
+// Creates the chart of the days (see decodeChart), weekStart is the
+// getDay() index of the first day of every week.
+function newChart(decoded, weekStart) {
 	let weeks = [];
 	let week = undefined;
 
-	while (true) {
-		date = new Date(date.getFullYear(), date.getMonth(), date.getDate() + 1, 12);
-		if (date.getFullYear() !== year) {
-			break;
-		}
+	for (let i = 0; i < decoded.days; i++) {
+		const date = new Date(decoded.start.getFullYear(), decoded.start.getMonth(), decoded.start.getDate() + i, 12);
 
-		if (date.getDay() === 0 || week === undefined) {
+		if (date.getDay() === weekStart || week === undefined) {
 			week = document.createElement("div");
 			week.classList.add("week");
 			weeks.push(week);
 		}
 
-		if (date.getMonth() === 0 && date.getDate() === 1) {
-			const day = date.getDay();
+		if (i === 0) {
+			const day = (date.getDay() - weekStart + 7) % 7;
 			for (let i = 0; i < day; i++) {
 				const day = document.createElement("div");
 				day.classList.add("no-day");
@@ -145,10 +180,12 @@ function newChart(year, stored) {
 
 		const day = document.createElement("div");
 		day.classList.add("day");
-		if (stored != null && stored.includes(date.getTime())) {
+		if (decoded.clicked.has(date.getTime())) {
 			day.classList.add("clicked");
+			day.dataset.level = decoded.clicked.get(date.getTime());
 		}
-		day.dataset.date = date.toISOString();
+		day.dataset.date = calendarDate(date);
+		day.dataset.time = date.getTime();
 		week.appendChild(day);
 	}
 
@@ -158,44 +195,69 @@ function newChart(year, stored) {
 	return res;
 }
 
+// Returns the days of the chart: { start: first day (at noon), days: number of days,
+// range: whether it is a range chart, clicked: Map of the set days (time at noon)
+// to their intensity level (1-4) }.
 function decodeChart(enc) {
-	if (enc[0] !== '0') {
+	// Versions "0" and "2" have 1 bit per day, versions "1" and "3" 4 bits (intensity level).
+	// Versions "2" and "3" are range charts, with a 5 byte header (year, day of year, weeks).
+	let bitsPerDay;
+	if (enc[0] === '0' || enc[0] === '2') {
+		bitsPerDay = 1;
+	} else if (enc[0] === '1' || enc[0] === '3') {
+		bitsPerDay = 4;
+	} else {
 		throw new Error("invalid encoding");
 	}
+	const range = enc[0] === '2' || enc[0] === '3';
+	const headerLen = range ? 5 : 2;
 
 	const arr = urlSafeBase64Decode(enc.substring(1));
 
-	if (arr.length < 2) {
+	if (arr.length <= headerLen || arr[arr.length-1] === 0) {
 		throw new Error("invalid encoding");
 	}
 
-	const year = (arr[0] << 8) | arr[1]
-
-	const clicked = [];
-	let lastZero = false;
-	arr.slice(2).forEach((v, i) => {
-		lastZero = false;
-		if (v == 0) {
-			lastZero = true;
+	const year = (arr[0] << 8) | arr[1];
+	let start = new Date(year, 0, 1, 12);
+	let days = Math.round((new Date(year + 1, 0, 1, 12) - start) / (24 * 60 * 60 * 1000));
+	if (range) {
+		start = new Date(year, 0, 1 + ((arr[2] << 8) | arr[3]), 12);
+		days = arr[4] * 7;
+		if (start.getFullYear() !== year || days === 0) {
+			throw new Error("invalid encoding");
 		}
-
-		for (let bit = 7; bit >= 0; bit--) {
-			if ((v & (1 << bit)) !== 0) {
-				const dayNum = 1 + i*8 + (7 - bit);
-				const date = new Date(year, 0, dayNum, 12);
-				if (date.getFullYear() !== year) {
-					throw new Error("invalid encoding");
-				}
-				clicked.push(date.getTime());
+	}
+	const daysPerByte = 8 / bitsPerDay;
+
+	const clicked = new Map();
+	arr.slice(headerLen).forEach((v, i) => {
+		for (let j = 0; j < daysPerByte; j++) {
+			const level = (v >> (8 - bitsPerDay*(j+1))) & ((1 << bitsPerDay) - 1);
+			if (level === 0) {
+				continue;
 			}
+			const dayNum = i*daysPerByte + j;
+			if (level > 4 || dayNum >= days) {
+				throw new Error("invalid encoding");
+			}
+			const date = new Date(start.getFullYear(), start.getMonth(), start.getDate() + dayNum, 12);
+			clicked.set(date.getTime(), bitsPerDay === 1 ? 4 : level);
 		}
 	});
 
-	if (lastZero) {
-		throw new Error("invalid encoding");
-	}
+	return { start: start, days: days, range: range, clicked: clicked };
+}
+
+// Returns the calendar date (YYYY-MM-DD) of the date in the local timezone.
+function calendarDate(date) {
+	const pad = (num, len) => String(num).padStart(len, "0");
+	return pad(date.getFullYear(), 4) + "-" + pad(date.getMonth() + 1, 2) + "-" + pad(date.getDate(), 2);
+}
 
-	return clicked;
+// Quotes s as a single POSIX shell word.
+function shellQuote(s) {
+	return "'" + s.replace(/'/g, "'\\''") + "'";
 }
 
 function urlSafeBase64Decode(arr) {
@@ -16,7 +16,7 @@ type githubUserIDKey uint8
 
 func (a *application) auth(handler errHandler) errHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		githubUserID, err := a.authenticate(r)
+		githubUserID, err := a.authenticate(w, r)
 		if err != nil {
 			var publicError service.PublicError
 			if errors.As(err, &publicError) {
@@ -42,18 +42,37 @@ func (a *application) getGithubUserID(r *http.Request) uint64 {
 	return r.Context().Value(githubUserIDKey(0)).(uint64)
 }
 
-func (a *application) authenticate(r *http.Request) (uint64, error) {
+// authenticate validates the session cookie, the cookie
+// is sent again when the session was renewed.
+func (a *application) authenticate(w http.ResponseWriter, r *http.Request) (uint64, error) {
 	cookie, err := r.Cookie("__Host-session")
 	if err != nil {
-		return 0, service.PublicWrapperError{errors.New("missing valid session cookie")}
+		return 0, service.PublicWrapperError{Err: errors.New("missing valid session cookie")}
 	}
 
-	githubUserID, err := a.sessionService.IsSessionValid(cookie.Value)
+	session, err := a.sessionService.IsSessionValid(cookie.Value)
 	if err != nil {
 		return 0, err
 	}
 
-	return githubUserID, nil
+	if session.Renewed {
+		setSessionCookie(w, session.Session, session.Expires)
+	}
+
+	return session.GithubUserID, nil
+}
+
+func setSessionCookie(w http.ResponseWriter, session string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "__Host-session",
+		Value:    session,
+		Path:     "/",
+		MaxAge:   int(time.Until(expires) / time.Second),
+		Expires:  expires,
+		SameSite: http.SameSiteLaxMode,
+		HttpOnly: true,
+		Secure:   true,
+	})
 }
 
 func (a *application) githubLogin(w http.ResponseWriter, r *http.Request) error {
@@ -138,7 +157,7 @@ func (a *application) githubLoginCallback(w http.ResponseWriter, r *http.Request
 		return err
 	}
 
-	s, err := a.sessionService.NewSession(userData.ID)
+	s, expires, err := a.sessionService.NewSession(userData.ID, r.UserAgent(), approximateIP(a.clientAddr(r)))
 	if err != nil {
 		return err
 	}
@@ -150,16 +169,7 @@ func (a *application) githubLoginCallback(w http.ResponseWriter, r *http.Request
 		MaxAge:  -1,
 		Secure:  true,
 	})
-	http.SetCookie(w, &http.Cookie{
-		Name:     "__Host-session",
-		Value:    s,
-		Path:     "/",
-		MaxAge:   3600 * 24 * 7,
-		Expires:  time.Now().Add(time.Hour * 24 * 7),
-		SameSite: http.SameSiteLaxMode,
-		HttpOnly: true,
-		Secure:   true,
-	})
+	setSessionCookie(w, s, expires)
 	http.Redirect(w, r, "/", http.StatusFound)
 	return nil
 }
@@ -182,6 +192,12 @@ func (a *application) logout(w http.ResponseWriter, r *http.Request) error {
 		}
 	}
 
+	removeSessionCookie(w)
+	http.Redirect(w, r, "/", http.StatusFound)
+	return nil
+}
+
+func removeSessionCookie(w http.ResponseWriter) {
 	http.SetCookie(w, &http.Cookie{
 		Name:    "__Host-session",
 		Path:    "/",
@@ -189,6 +205,4 @@ func (a *application) logout(w http.ResponseWriter, r *http.Request) error {
 		MaxAge:  -1,
 		Secure:  true,
 	})
-	http.Redirect(w, r, "/", http.StatusFound)
-	return nil
 }
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mateusz834/charts/service"
+)
+
+// fakeSessionService accepts only the session "valid".
+type fakeSessionService struct {
+	valid service.ValidSession
+}
+
+func (f *fakeSessionService) NewSession(githubUserID uint64, userAgent, ip string) (string, time.Time, error) {
+	return "valid", f.valid.Expires, nil
+}
+
+func (f *fakeSessionService) IsSessionValid(session string) (service.ValidSession, error) {
+	if session != "valid" {
+		return service.ValidSession{}, service.PublicWrapperError{Err: errors.New("session not found")}
+	}
+	return f.valid, nil
+}
+
+func (f *fakeSessionService) RemoveSession(session string) error {
+	return nil
+}
+
+func (f *fakeSessionService) GetUserSessions(session string) ([]service.SessionInfo, error) {
+	return nil, nil
+}
+
+func (f *fakeSessionService) RevokeSession(githubUserID uint64, id int64) error {
+	return nil
+}
+
+func (f *fakeSessionService) RemoveUserSessions(githubUserID uint64) error {
+	return nil
+}
+
+func TestAuthenticateRenewal(t *testing.T) {
+	expires := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+
+	for _, renewed := range []bool{false, true} {
+		// A renewed session replaces the one sent by the client.
+		session := "valid"
+		if renewed {
+			session = "renewed"
+		}
+		a := &application{sessionService: &fakeSessionService{
+			valid: service.ValidSession{GithubUserID: 1, Session: session, Expires: expires, Renewed: renewed},
+		}}
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: "__Host-session", Value: "valid"})
+		w := httptest.NewRecorder()
+
+		githubUserID, err := a.authenticate(w, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if githubUserID != 1 {
+			t.Errorf("renewed = %v: authenticate() = %v, want: 1", renewed, githubUserID)
+		}
+
+		cookies := w.Result().Cookies()
+		if !renewed {
+			if len(cookies) != 0 {
+				t.Errorf("renewed = %v: unexpected cookies: %v", renewed, cookies)
+			}
+			continue
+		}
+		if len(cookies) != 1 {
+			t.Fatalf("renewed = %v: got cookies: %v, want one session cookie", renewed, cookies)
+		}
+		c := cookies[0]
+		if c.Name != "__Host-session" || c.Value != "renewed" || !c.Expires.Equal(expires) || c.MaxAge <= 0 ||
+			!c.Secure || !c.HttpOnly || c.Path != "/" {
+			t.Errorf("renewed = %v: unexpected session cookie: %#v", renewed, c)
+		}
+	}
+
+	a := &application{sessionService: &fakeSessionService{}}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "__Host-session", Value: "invalid"})
+	w := httptest.NewRecorder()
+	if _, err := a.authenticate(w, r); err == nil {
+		t.Error("authenticate() with an invalid session succeeded")
+	}
+	if cookies := w.Result().Cookies(); len(cookies) != 0 {
+		t.Errorf("unexpected cookies after an invalid session: %v", cookies)
+	}
+}
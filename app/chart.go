@@ -0,0 +1,295 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+type chartErrResponse struct {
+	ErrorType string `json:"error_type"`
+	ErrorMsg  string `json:"error_msg"`
+}
+
+// parseWeekStart parses the "sunday" or "monday" week start,
+// an empty string means sunday (the same as on github).
+func parseWeekStart(s string) (time.Weekday, error) {
+	if s == "" {
+		return time.Sunday, nil
+	}
+	return chart.ParseWeekStart(s)
+}
+
+const maxTextLength = 256
+
+func (a *application) textChart(w http.ResponseWriter, r *http.Request) error {
+	reqBody := struct {
+		Text      string `json:"text"`
+		Year      int    `json:"year"`
+		Align     string `json:"align"`
+		StartWeek int    `json:"start_week"`
+		Spacing   *int   `json:"spacing"`
+		Level     int    `json:"level"`
+		WeekStart string `json:"week_start"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+
+	sendErr := func(msg string) error {
+		return sendJSON(w, http.StatusOK, chartErrResponse{ErrorType: "text", ErrorMsg: msg})
+	}
+
+	if len(reqBody.Text) > maxTextLength {
+		return sendErr("text is too long")
+	}
+
+	var err error
+	o := chart.DefaultTextOptions()
+	o.StartWeek = reqBody.StartWeek
+	if reqBody.Spacing != nil {
+		o.Spacing = *reqBody.Spacing
+	}
+
+	switch reqBody.Align {
+	case "", "left":
+		o.Align = chart.AlignLeft
+	case "center":
+		o.Align = chart.AlignCenter
+	case "right":
+		o.Align = chart.AlignRight
+	default:
+		return sendErr("unknown alignment")
+	}
+
+	if reqBody.Level < 0 || reqBody.Level > int(chart.MaxLevel) {
+		return sendErr("intensity level out of range")
+	}
+	o.Level = chart.Level(reqBody.Level)
+
+	if o.WeekStart, err = parseWeekStart(reqBody.WeekStart); err != nil {
+		return sendErr(err.Error())
+	}
+
+	c, layout, err := chart.Text(reqBody.Year, reqBody.Text, &o)
+	if err != nil {
+		return sendErr(err.Error())
+	}
+
+	if c.Count() == 0 {
+		return sendErr("text does not fit in the chart")
+	}
+
+	encoded, err := chart.Encode(c)
+	if err != nil {
+		return err
+	}
+
+	type response struct {
+		Chart     string `json:"chart"`
+		FirstWeek int    `json:"first_week"`
+		Width     int    `json:"width"`
+		Clipped   int    `json:"clipped"`
+	}
+
+	return sendJSON(w, http.StatusOK, response{
+		Chart:     encoded,
+		FirstWeek: layout.FirstWeek,
+		Width:     layout.Width,
+		Clipped:   layout.Clipped,
+	})
+}
+
+const (
+	maxImportImageSize       = 1 << 20
+	maxImportImageDimensions = 2048
+)
+
+// importImage accepts an image (PNG, GIF or JPEG) as the request body
+// and converts it to a chart, see the "/import-image" route.
+func (a *application) importImage(w http.ResponseWriter, r *http.Request) error {
+	sendErr := func(msg string) error {
+		return sendJSON(w, http.StatusOK, chartErrResponse{ErrorType: "image", ErrorMsg: msg})
+	}
+
+	query := r.URL.Query()
+	year, err := strconv.Atoi(query.Get("year"))
+	if err != nil {
+		return sendErr("invalid year")
+	}
+
+	o := chart.DefaultImageOptions()
+	o.Levels = query.Get("levels") == "1"
+	o.Invert = query.Get("invert") == "1"
+	if v := query.Get("threshold"); v != "" {
+		o.Threshold, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return sendErr("invalid threshold")
+		}
+	}
+	if o.WeekStart, err = parseWeekStart(query.Get("week_start")); err != nil {
+		return sendErr(err.Error())
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportImageSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return sendErr("image is too big")
+		}
+		return &debugError{err}
+	}
+
+	// Check the dimensions before decoding, so that small
+	// files cannot make us allocate huge images.
+	config, _, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		return sendErr("unsupported image format")
+	}
+	if config.Width > maxImportImageDimensions || config.Height > maxImportImageDimensions {
+		return sendErr("image dimensions are too big")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return sendErr("failed to decode image")
+	}
+
+	c, err := chart.FromImage(year, img, &o)
+	if err != nil {
+		return sendErr(err.Error())
+	}
+
+	if c.Count() == 0 {
+		return sendErr("image does not set any day")
+	}
+
+	encoded, err := chart.Encode(c)
+	if err != nil {
+		return err
+	}
+
+	type response struct {
+		Chart   string `json:"chart"`
+		Preview string `json:"preview"`
+	}
+
+	return sendJSON(w, http.StatusOK, response{
+		Chart:   encoded,
+		Preview: "/render.png?c=" + url.QueryEscape(encoded),
+	})
+}
+
+const maxChartOpCharts = 16
+
+// chartOp applies a chart operation, see the "/chart-op" route.
+func (a *application) chartOp(w http.ResponseWriter, r *http.Request) error {
+	reqBody := struct {
+		Op        string   `json:"op"`
+		Charts    []string `json:"charts"`
+		Days      int      `json:"days"`
+		Weeks     int      `json:"weeks"`
+		Year      int      `json:"year"`
+		Start     string   `json:"start"`
+		WeekStart string   `json:"week_start"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+
+	sendErr := func(msg string) error {
+		return sendJSON(w, http.StatusOK, chartErrResponse{ErrorType: "chart", ErrorMsg: msg})
+	}
+
+	if len(reqBody.Charts) == 0 || len(reqBody.Charts) > maxChartOpCharts {
+		return sendErr(fmt.Sprintf("expected 1 to %v charts", maxChartOpCharts))
+	}
+
+	charts := make([]*chart.Chart, len(reqBody.Charts))
+	for i, v := range reqBody.Charts {
+		c, err := chart.Decode(v)
+		if err != nil {
+			return sendErr(fmt.Sprintf("chart %v: %v", i, err))
+		}
+		charts[i] = c
+	}
+
+	weekStart, err := parseWeekStart(reqBody.WeekStart)
+	if err != nil {
+		return sendErr(err.Error())
+	}
+
+	var res *chart.Chart
+
+	// Binary operations are applied from left to right on all charts.
+	binary := map[string]func(a, b *chart.Chart) (*chart.Chart, error){
+		"union":        chart.Union,
+		"intersection": chart.Intersection,
+		"difference":   chart.Difference,
+	}
+
+	if op, ok := binary[reqBody.Op]; ok {
+		res = charts[0]
+		for _, v := range charts[1:] {
+			if res, err = op(res, v); err != nil {
+				return sendErr(err.Error())
+			}
+		}
+	} else {
+		if len(charts) != 1 {
+			return sendErr("expected exactly one chart")
+		}
+		switch reqBody.Op {
+		case "invert":
+			res = chart.Invert(charts[0])
+		case "shift":
+			res = chart.Shift(charts[0], reqBody.Weeks*7+reqBody.Days)
+		case "mirror-horizontal":
+			res, err = chart.MirrorHorizontal(charts[0], weekStart)
+		case "mirror-vertical":
+			res, err = chart.MirrorVertical(charts[0], weekStart)
+		case "retarget":
+			res, err = chart.Retarget(charts[0], reqBody.Year, weekStart)
+		case "retarget-range":
+			start, parseErr := time.Parse(time.DateOnly, reqBody.Start)
+			if parseErr != nil {
+				return sendErr("invalid start date")
+			}
+			res, err = chart.RetargetRange(charts[0], start, reqBody.Weeks, weekStart)
+		default:
+			return sendErr("unknown operation")
+		}
+		if err != nil {
+			return sendErr(err.Error())
+		}
+	}
+
+	if res.Count() == 0 {
+		return sendErr("result does not have any day set")
+	}
+
+	encoded, err := chart.Encode(res)
+	if err != nil {
+		return err
+	}
+
+	type response struct {
+		Chart string `json:"chart"`
+	}
+
+	return sendJSON(w, http.StatusOK, response{Chart: encoded})
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+	"github.com/mateusz834/charts/render"
+)
+
+// gifOptions parses the animation options from url query params, besides
+// the options of renderOptions it accepts:
+// - "delay" -> time that every frame is shown, in milliseconds.
+// - "loops" -> number of times the animation is played, "0" (default) means forever.
+func gifOptions(query url.Values, weekStart time.Weekday) (render.GIFOptions, error) {
+	o := render.DefaultGIFOptions()
+
+	var err error
+	o.Options, err = renderOptions(query, weekStart)
+	if err != nil {
+		return o, err
+	}
+
+	if v := query.Get("delay"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil {
+			return o, fmt.Errorf("invalid \"delay\" query param: %v", err)
+		}
+		o.Delay = time.Duration(ms) * time.Millisecond
+	}
+
+	if v := query.Get("loops"); v != "" {
+		o.Loops, err = strconv.Atoi(v)
+		if err != nil {
+			return o, fmt.Errorf("invalid \"loops\" query param: %v", err)
+		}
+	}
+
+	return o, o.Validate()
+}
+
+func sendGIF(w http.ResponseWriter, r *http.Request, frames []*chart.Chart, weekStart time.Weekday) error {
+	o, err := gifOptions(r.URL.Query(), weekStart)
+	if err != nil {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+
+	var buf bytes.Buffer
+	if err := render.GIF(&buf, frames, &o); err != nil {
+		if errors.Is(err, render.ErrGIFTooLarge) {
+			return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+		}
+		return err
+	}
+	return sendBuffered(w, "image/gif", &buf)
+}
+
+// shareGIF sends the share (followed by its frames) as an animated GIF.
+func (a *application) shareGIF(w http.ResponseWriter, r *http.Request, path string) error {
+	share, err := a.getShare(path)
+	if err != nil {
+		return err
+	}
+	return sendGIF(w, r, share.AllFrames(), share.WeekStart)
+}
+
+// renderGIF sends the frames from the "c" query param (see chart.DecodeFrames) as an animated GIF.
+func (a *application) renderGIF(w http.ResponseWriter, r *http.Request) error {
+	frames, err := chart.DecodeFrames(r.URL.Query().Get("c"))
+	if err != nil {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+	return sendGIF(w, r, frames, time.Sunday)
+}
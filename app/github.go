@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 )
 
 type githubUser struct {
@@ -39,3 +42,98 @@ func getGithubUserData(accessToken string) (*githubUser, error) {
 
 	return &user, nil
 }
+
+// githubUserCache caches public github user data, so that rendering
+// of share pages does not hit the (rate limited) github API every time.
+// Failures are cached too (for a shorter time), so that an unavailable
+// (or rate limited) API does not slow down every share page.
+type githubUserCache struct {
+	mu    sync.Mutex
+	users map[uint64]cachedGithubUser
+}
+
+type cachedGithubUser struct {
+	user    *githubUser
+	err     error
+	expires time.Time
+}
+
+const (
+	// maxCachedGithubUsers limits the memory used by githubUserCache.
+	maxCachedGithubUsers = 4096
+
+	githubUserCacheTTL        = 24 * time.Hour
+	githubUserFailureCacheTTL = 10 * time.Minute
+)
+
+func (c *githubUserCache) get(id uint64) (*githubUser, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	cached, ok := c.users[id]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.user, cached.err
+	}
+
+	user, err := getGithubUserByID(id)
+	cached = cachedGithubUser{user: user, err: err, expires: now.Add(githubUserCacheTTL)}
+	if err != nil {
+		cached.expires = now.Add(githubUserFailureCacheTTL)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.users == nil {
+		c.users = make(map[uint64]cachedGithubUser)
+	}
+	if _, ok := c.users[id]; !ok && len(c.users) >= maxCachedGithubUsers {
+		c.evict(now)
+	}
+	c.users[id] = cached
+	return user, err
+}
+
+// evict removes the expired entries, or a random one when none is expired.
+func (c *githubUserCache) evict(now time.Time) {
+	for k, v := range c.users {
+		if !now.Before(v.expires) {
+			delete(c.users, k)
+		}
+	}
+	if len(c.users) < maxCachedGithubUsers {
+		return
+	}
+	for k := range c.users {
+		delete(c.users, k)
+		return
+	}
+}
+
+func getGithubUserByID(id uint64) (*githubUser, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://api.github.com/user/"+strconv.FormatUint(id, 10), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed while creting github user data request: %v", err)
+	}
+
+	req.Header.Add("Accept", "application/vnd.github+json")
+	req.Header.Add("X-GitHub-Api-Version", "2022-11-28")
+
+	client := http.Client{Timeout: 5 * time.Second}
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed while sending github user data request: %v", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed while retreiving github user data, got status: %v", response.Status)
+	}
+
+	var user githubUser
+	if err := json.NewDecoder(response.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed while json unmarshaling: %v", err)
+	}
+
+	return &user, nil
+}
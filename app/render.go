@@ -0,0 +1,179 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+	"github.com/mateusz834/charts/render"
+	"github.com/mateusz834/charts/service"
+)
+
+// renderOptions parses the rendering options from url query params:
+// - "cell" -> cell size in pixels.
+// - "gap" -> gap between cells in pixels.
+// - "theme" -> "light" (default) or "dark".
+// - "colors" -> five comma separated hex colors (without '#') of each intensity level.
+// - "labels" -> "0" disables month and weekday labels.
+// - "week_start" -> "sunday" or "monday", defaults to weekStart.
+func renderOptions(query url.Values, weekStart time.Weekday) (render.Options, error) {
+	o := render.DefaultOptions()
+	o.WeekStart = weekStart
+
+	parseInt := func(name string, dst *int) error {
+		if v := query.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid %q query param: %v", name, err)
+			}
+			*dst = n
+		}
+		return nil
+	}
+
+	if err := parseInt("cell", &o.CellSize); err != nil {
+		return o, err
+	}
+	if err := parseInt("gap", &o.CellGap); err != nil {
+		return o, err
+	}
+
+	switch query.Get("theme") {
+	case "", "light":
+	case "dark":
+		o.Theme = render.DarkTheme
+	default:
+		return o, errors.New("unknown theme")
+	}
+
+	if v := query.Get("colors"); v != "" {
+		colors := strings.Split(v, ",")
+		if len(colors) != len(o.Theme.Levels) {
+			return o, fmt.Errorf("expected %v colors", len(o.Theme.Levels))
+		}
+		for i, v := range colors {
+			c, err := parseHexColor(v)
+			if err != nil {
+				return o, err
+			}
+			o.Theme.Levels[i] = c
+		}
+	}
+
+	if query.Get("labels") == "0" {
+		o.Labels = false
+	}
+
+	if v := query.Get("week_start"); v != "" {
+		weekStart, err := chart.ParseWeekStart(v)
+		if err != nil {
+			return o, err
+		}
+		o.WeekStart = weekStart
+	}
+
+	return o, o.Validate()
+}
+
+func parseHexColor(s string) (color.RGBA, error) {
+	if len(s) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid color: %q", s)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color: %q", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// getShare returns the share, a not existing share is
+// reported as a httpError with 404 status code.
+func (a *application) getShare(path string) (*service.Share, error) {
+	share, err := a.publicSharesService.GetShare(path)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			return nil, &httpError{ResponseCode: http.StatusNotFound, DebugErr: err}
+		}
+		return nil, err
+	}
+	return share, nil
+}
+
+// queryChart decodes the chart from the "c" query param.
+func queryChart(r *http.Request) (*chart.Chart, error) {
+	c, err := chart.Decode(r.URL.Query().Get("c"))
+	if err != nil {
+		return nil, &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+	return c, nil
+}
+
+type renderFormat struct {
+	contentType string
+	render      func(w io.Writer, c *chart.Chart, o *render.Options) error
+}
+
+var (
+	svgFormat = renderFormat{contentType: "image/svg+xml", render: render.SVG}
+	pngFormat = renderFormat{contentType: "image/png", render: render.PNG}
+)
+
+// renderFormats maps a file extension of the request path to a renderFormat.
+var renderFormats = map[string]renderFormat{
+	".svg": svgFormat,
+	".png": pngFormat,
+}
+
+// sendRendered sends the chart rendered in the format, weekStart is the
+// default week start, when not specified in the query params.
+func sendRendered(w http.ResponseWriter, r *http.Request, c *chart.Chart, weekStart time.Weekday, format renderFormat) error {
+	o, err := renderOptions(r.URL.Query(), weekStart)
+	if err != nil {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+
+	// Render before sending the headers, so that errors can be reported.
+	var buf bytes.Buffer
+	if err := format.render(&buf, c, &o); err != nil {
+		if errors.Is(err, render.ErrPNGTooLarge) {
+			return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+		}
+		return err
+	}
+	return sendBuffered(w, format.contentType, &buf)
+}
+
+// sendBuffered sends the already rendered content with a Content-Length header.
+func sendBuffered(w http.ResponseWriter, contentType string, content *bytes.Buffer) error {
+	w.Header().Set("Content-Length", strconv.Itoa(content.Len()))
+	return sendFunc(w, http.StatusOK, contentType, func(w io.Writer) error {
+		_, err := content.WriteTo(w)
+		return err
+	})
+}
+
+func (a *application) shareRender(w http.ResponseWriter, r *http.Request, path string, format renderFormat) error {
+	share, err := a.getShare(path)
+	if err != nil {
+		return err
+	}
+	return sendRendered(w, r, share.Chart, share.WeekStart, format)
+}
+
+func (a *application) renderChart(format renderFormat) errHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		c, err := queryChart(r)
+		if err != nil {
+			return err
+		}
+		return sendRendered(w, r, c, time.Sunday, format)
+	}
+}
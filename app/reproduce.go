@@ -0,0 +1,119 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+	"github.com/mateusz834/charts/reproduce"
+)
+
+// reproduceOptions parses the reproducer options from url query params:
+// - "message" -> commit message (see reproduce.Options.Message).
+// - "name", "email" -> commit author and committer.
+// - "tz" -> IANA timezone name or UTC offset of the commit dates, defaults to loc.
+// - "commits" -> commits per day (see reproduce.Options.CommitsPerDay).
+func reproduceOptions(query url.Values, loc *time.Location) (reproduce.Options, error) {
+	o := reproduce.DefaultOptions()
+	o.Location = loc
+
+	if query.Has("message") {
+		o.Message = query.Get("message")
+	}
+	o.AuthorName = query.Get("name")
+	o.AuthorEmail = query.Get("email")
+
+	if v := query.Get("tz"); v != "" {
+		loc, err := chart.ParseLocation(v)
+		if err != nil {
+			return o, err
+		}
+		o.Location = loc
+	}
+
+	if v := query.Get("commits"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return o, fmt.Errorf("invalid \"commits\" query param: %v", err)
+		}
+		o.CommitsPerDay = n
+	}
+
+	return o, nil
+}
+
+// sendAttachment sends the content as a downloadable file.
+func sendAttachment(w http.ResponseWriter, contentType, filename string, content *bytes.Buffer) error {
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	return sendBuffered(w, contentType, content)
+}
+
+// shareReproduceScript sends a script that reproduces the share in the timezone
+// of the share creator (unless overridden by "tz"), besides the
+// options of reproduceOptions it accepts the "shell" query param (see reproduce.ParseShell).
+func (a *application) shareReproduceScript(w http.ResponseWriter, r *http.Request, path string) error {
+	share, err := a.getShare(path)
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+	o := reproduce.ScriptOptions{Shell: reproduce.Bash}
+	o.Options, err = reproduceOptions(query, share.Location())
+	if err != nil {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+
+	if v := query.Get("shell"); v != "" {
+		o.Shell, err = reproduce.ParseShell(v)
+		if err != nil {
+			return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := reproduce.Script(&buf, share.Chart, &o); err != nil {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+
+	return sendAttachment(w, "text/plain; charset=utf-8", "reproduce"+o.Shell.Extension(), &buf)
+}
+
+// shareFastImport sends a git fast-import stream that reproduces the share in the timezone
+// of the share creator (unless overridden by "tz"), besides the
+// options of reproduceOptions it accepts the "branch" and "file" query params.
+// The "email" query param is required (see reproduce.ErrMissingEmail).
+func (a *application) shareFastImport(w http.ResponseWriter, r *http.Request, path string) error {
+	share, err := a.getShare(path)
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+	o := reproduce.DefaultFastImportOptions()
+	o.Options, err = reproduceOptions(query, share.Location())
+	if err != nil {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+	if o.AuthorEmail == "" {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: reproduce.ErrMissingEmail}
+	}
+
+	if query.Has("branch") {
+		o.Branch = query.Get("branch")
+	}
+	if query.Has("file") {
+		o.File = query.Get("file")
+	}
+
+	var buf bytes.Buffer
+	if err := reproduce.FastImport(&buf, share.Chart, &o); err != nil {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+
+	return sendAttachment(w, "text/plain; charset=utf-8", "fast-import", &buf)
+}
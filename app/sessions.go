@@ -0,0 +1,116 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mateusz834/charts/service"
+)
+
+// approximateIP returns the ip address of remoteAddr, with the last
+// octet (IPv4) or the last 80 bits (IPv6) zeroed.
+func approximateIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ""
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// clientAddr returns the address of the client. Behind a proxy the RemoteAddr is the address
+// of the proxy, so the address is taken from the trustedProxyHeader (when it is set):
+// from the last X-Forwarded-For entry (the one appended by the proxy, the previous
+// ones are sent by the client) or from X-Real-IP.
+func (a *application) clientAddr(r *http.Request) string {
+	switch a.trustedProxyHeader {
+	case "X-Forwarded-For":
+		if values := r.Header.Values("X-Forwarded-For"); len(values) != 0 {
+			entries := strings.Split(values[len(values)-1], ",")
+			return strings.TrimSpace(entries[len(entries)-1])
+		}
+	case "X-Real-Ip":
+		if v := r.Header.Get("X-Real-Ip"); v != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return r.RemoteAddr
+}
+
+func (a *application) getAllUserSessions(w http.ResponseWriter, r *http.Request) error {
+	cookie, err := r.Cookie("__Host-session")
+	if err != nil {
+		return err
+	}
+
+	sessions, err := a.sessionService.GetUserSessions(cookie.Value)
+	if err != nil {
+		return err
+	}
+
+	type session struct {
+		ID         int64  `json:"id"`
+		CreatedAt  string `json:"created_at"`
+		LastSeenAt string `json:"last_seen_at"`
+		UserAgent  string `json:"user_agent"`
+		IP         string `json:"ip"`
+		Current    bool   `json:"current"`
+	}
+
+	res := make([]session, len(sessions))
+	for i, v := range sessions {
+		res[i] = session{
+			ID:         v.ID,
+			CreatedAt:  v.CreatedAt.UTC().Format(time.RFC3339),
+			LastSeenAt: v.LastSeenAt.UTC().Format(time.RFC3339),
+			UserAgent:  v.UserAgent,
+			IP:         v.IP,
+			Current:    v.Current,
+		}
+	}
+
+	return sendJSON(w, http.StatusOK, res)
+}
+
+func (a *application) revokeSession(w http.ResponseWriter, r *http.Request) error {
+	reqBody := struct {
+		ID int64 `json:"id"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+
+	type errResponse struct {
+		ErrorType string `json:"error_type"`
+		ErrorMsg  string `json:"error_msg"`
+	}
+
+	if err := a.sessionService.RevokeSession(a.getGithubUserID(r), reqBody.ID); err != nil {
+		var publicError service.PublicError
+		if errors.As(err, &publicError) {
+			return sendJSON(w, http.StatusOK, errResponse{ErrorType: "session", ErrorMsg: publicError.PublicError()})
+		}
+		return err
+	}
+
+	return sendJSON(w, http.StatusOK, struct{}{})
+}
+
+func (a *application) logoutEverywhere(w http.ResponseWriter, r *http.Request) error {
+	if err := a.sessionService.RemoveUserSessions(a.getGithubUserID(r)); err != nil {
+		return err
+	}
+	removeSessionCookie(w)
+	return sendJSON(w, http.StatusOK, struct{}{})
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientAddr(t *testing.T) {
+	tests := []struct {
+		trustedProxyHeader string
+		header             http.Header
+		want               string
+	}{
+		{"", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "192.0.2.1:1234"},
+		{"X-Forwarded-For", http.Header{}, "192.0.2.1:1234"},
+		{"X-Forwarded-For", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "198.51.100.1"},
+		{"X-Forwarded-For", http.Header{"X-Forwarded-For": {"203.0.113.1, 198.51.100.1"}}, "198.51.100.1"},
+		{"X-Forwarded-For", http.Header{"X-Forwarded-For": {"203.0.113.1", "198.51.100.1"}}, "198.51.100.1"},
+		{"X-Forwarded-For", http.Header{"X-Real-Ip": {"198.51.100.1"}}, "192.0.2.1:1234"},
+		{"X-Real-IP", http.Header{"X-Real-Ip": {"198.51.100.1"}}, "198.51.100.1"},
+		{"X-Real-IP", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "192.0.2.1:1234"},
+	}
+
+	for _, tt := range tests {
+		a := NewApplication(OAuth{}, nil, tt.trustedProxyHeader, nil, nil, nil)
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "192.0.2.1:1234"
+		r.Header = tt.header
+		if got := a.clientAddr(r); got != tt.want {
+			t.Errorf("%q: clientAddr(%v) = %q, want: %q", tt.trustedProxyHeader, tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestApproximateIP(t *testing.T) {
+	for addr, want := range map[string]string{
+		"192.0.2.123:1234":         "192.0.2.0",
+		"198.51.100.1":             "198.51.100.0",
+		"[2001:db8:1:2:3::1]:1234": "2001:db8:1::",
+		"2001:db8:1:2:3::1":        "2001:db8:1::",
+		"invalid":                  "",
+	} {
+		if got := approximateIP(addr); got != want {
+			t.Errorf("approximateIP(%q) = %q, want: %q", addr, got, want)
+		}
+	}
+}
@@ -3,10 +3,16 @@ package app
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/mateusz834/charts/chart"
 	"github.com/mateusz834/charts/service"
+	"github.com/mateusz834/charts/templates"
 )
 
 func (a *application) validatePath(w http.ResponseWriter, r *http.Request) error {
@@ -45,8 +51,11 @@ func (a *application) validatePath(w http.ResponseWriter, r *http.Request) error
 
 func (a *application) createShare(w http.ResponseWriter, r *http.Request) error {
 	reqBody := struct {
-		CustomPath *string `json:"custom_path"`
-		Chart      string  `json:"chart"`
+		CustomPath *string  `json:"custom_path"`
+		Chart      string   `json:"chart"`
+		Timezone   string   `json:"timezone"`
+		WeekStart  string   `json:"week_start"`
+		Frames     []string `json:"frames"`
 	}{}
 
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
@@ -59,8 +68,11 @@ func (a *application) createShare(w http.ResponseWriter, r *http.Request) error
 	}
 
 	createShare := &service.CreateShare{
-		EncodedChart: reqBody.Chart,
-		GithubUserID: a.getGithubUserID(r),
+		EncodedChart:  reqBody.Chart,
+		Timezone:      reqBody.Timezone,
+		WeekStart:     reqBody.WeekStart,
+		EncodedFrames: reqBody.Frames,
+		GithubUserID:  a.getGithubUserID(r),
 	}
 
 	if reqBody.CustomPath != nil {
@@ -94,14 +106,77 @@ func (a *application) shareInfo(w http.ResponseWriter, r *http.Request) error {
 		return nil
 	}
 
+	encodedChart, err := share.EncodedChart()
+	if err != nil {
+		return err
+	}
+
 	type response struct {
-		Chart        string `json:"chart"`
-		GithubUserID uint64 `json:"github_user_id"`
+		Chart        string   `json:"chart"`
+		GithubUserID uint64   `json:"github_user_id"`
+		Timezone     string   `json:"timezone"`
+		WeekStart    string   `json:"week_start"`
+		Frames       []string `json:"frames"`
+	}
+
+	frames := make([]string, len(share.Frames))
+	for i, v := range share.Frames {
+		if frames[i], err = chart.Encode(v); err != nil {
+			return err
+		}
 	}
 
 	return sendJSON(w, http.StatusOK, response{
-		Chart:        share.EncodedChart,
+		Chart:        encodedChart,
 		GithubUserID: share.GithubUserID,
+		Timezone:     share.Timezone,
+		WeekStart:    strings.ToLower(share.WeekStart.String()),
+		Frames:       frames,
+	})
+}
+
+func (a *application) sharePage(w http.ResponseWriter, r *http.Request, path string) error {
+	share, err := a.publicSharesService.GetShare(path)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			http.NotFound(w, r)
+			return nil
+		}
+		return err
+	}
+
+	owner := fmt.Sprintf("github user #%v", share.GithubUserID)
+	if user, err := a.githubUsers.get(share.GithubUserID); err == nil {
+		owner = user.Login
+	} else {
+		a.log.Debug(fmt.Sprintf("failed to get github user of share %q: %v", path, err))
+	}
+
+	// The request host is not trusted, the links are only included
+	// when the public URL of the server is configured.
+	var shareURL, imageURL string
+	if a.publicURL != nil {
+		shareURL = a.publicURL.JoinPath("s", path).String()
+		imageURL = a.publicURL.JoinPath("s", path+".png").String()
+	}
+
+	period := strconv.Itoa(share.Chart.Year())
+	if share.Chart.IsRange() {
+		period = share.Chart.Start().Format(time.DateOnly) + " to " + share.Chart.End().Format(time.DateOnly)
+	}
+	days := share.Chart.Count()
+	daysNoun := "days"
+	if days == 1 {
+		daysNoun = "day"
+	}
+
+	return sendHTMLFunc(w, http.StatusOK, func(w io.Writer) error {
+		return templates.Share(w, &templates.ShareData{
+			Title:       fmt.Sprintf("%v chart by %v", period, owner),
+			Description: fmt.Sprintf("Github summary chart of %v created by %v, with %v %v selected.", period, owner, days, daysNoun),
+			URL:         shareURL,
+			ImageURL:    imageURL,
+		})
 	})
 }
 
@@ -112,13 +187,22 @@ func (a *application) getAllUserShares(w http.ResponseWriter, r *http.Request) e
 	}
 
 	type share struct {
-		Path  string `json:"path"`
-		Chart string `json:"chart"`
+		Path      string `json:"path"`
+		Chart     string `json:"chart"`
+		WeekStart string `json:"week_start"`
 	}
 
 	res := make([]share, len(shares))
 	for i, v := range shares {
-		res[i] = share{Path: v.Path, Chart: v.EncodedChart}
+		encodedChart, err := v.EncodedChart()
+		if err != nil {
+			return err
+		}
+		res[i] = share{
+			Path:      v.Path,
+			Chart:     encodedChart,
+			WeekStart: strings.ToLower(v.WeekStart.String()),
+		}
 	}
 
 	return sendJSON(w, http.StatusOK, res)
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+// statsOptions parses the statistics options from url query params:
+// - "today" -> date (YYYY-MM-DD) that future days are relative to, defaults
+// to the current date in the "tz" timezone (IANA name or UTC offset), or loc.
+// - "week_start" -> "sunday" or "monday", defaults to weekStart.
+func statsOptions(query url.Values, loc *time.Location, weekStart time.Weekday) (chart.StatsOptions, error) {
+	o := chart.StatsOptions{WeekStart: weekStart}
+
+	if v := query.Get("tz"); v != "" {
+		var err error
+		loc, err = chart.ParseLocation(v)
+		if err != nil {
+			return o, err
+		}
+	}
+
+	o.Today = time.Now().In(loc)
+	if v := query.Get("today"); v != "" {
+		today, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return o, fmt.Errorf("invalid \"today\" query param: %v", err)
+		}
+		o.Today = today
+	}
+
+	if v := query.Get("week_start"); v != "" {
+		var err error
+		o.WeekStart, err = chart.ParseWeekStart(v)
+		if err != nil {
+			return o, err
+		}
+	}
+
+	return o, nil
+}
+
+func sendStats(w http.ResponseWriter, r *http.Request, c *chart.Chart, loc *time.Location, weekStart time.Weekday) error {
+	o, err := statsOptions(r.URL.Query(), loc, weekStart)
+	if err != nil {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+
+	s, err := c.Stats(&o)
+	if err != nil {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+
+	// The statistics relative to the current date change every day.
+	if r.URL.Query().Get("today") == "" {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	type period struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+		Days  int    `json:"days"`
+	}
+
+	type month struct {
+		Year  int `json:"year"`
+		Month int `json:"month"`
+		Days  int `json:"days"`
+	}
+
+	type response struct {
+		Days          int      `json:"days"`
+		Months        []month  `json:"months"`
+		Weekdays      [7]int   `json:"weekdays"`
+		LongestStreak *period  `json:"longest_streak"`
+		LongestGap    *period  `json:"longest_gap"`
+		WeeksTouched  int      `json:"weeks_touched"`
+		Today         string   `json:"today"`
+		FutureDays    []string `json:"future_days"`
+	}
+
+	toPeriod := func(p chart.Period) *period {
+		if p.Days == 0 {
+			return nil
+		}
+		return &period{Start: p.Start.Format(time.DateOnly), End: p.End.Format(time.DateOnly), Days: p.Days}
+	}
+
+	res := response{
+		Days:          s.Days,
+		Months:        make([]month, len(s.Months)),
+		Weekdays:      s.Weekdays,
+		LongestStreak: toPeriod(s.LongestStreak),
+		LongestGap:    toPeriod(s.LongestGap),
+		WeeksTouched:  s.WeeksTouched,
+		Today:         o.Today.Format(time.DateOnly),
+		FutureDays:    make([]string, len(s.FutureDays)),
+	}
+	for i, v := range s.Months {
+		res.Months[i] = month{Year: v.Year, Month: int(v.Month), Days: v.Days}
+	}
+	for i, v := range s.FutureDays {
+		res.FutureDays[i] = v.Format(time.DateOnly)
+	}
+
+	return sendJSON(w, http.StatusOK, res)
+}
+
+// shareStats sends the statistics of the share, future days are by default
+// relative to the current date in the timezone of the share creator.
+func (a *application) shareStats(w http.ResponseWriter, r *http.Request, path string) error {
+	share, err := a.getShare(path)
+	if err != nil {
+		return err
+	}
+	return sendStats(w, r, share.Chart, share.Location(), share.WeekStart)
+}
+
+// chartStats sends the statistics of the chart from the "c" query param.
+func (a *application) chartStats(w http.ResponseWriter, r *http.Request) error {
+	c, err := queryChart(r)
+	if err != nil {
+		return err
+	}
+	return sendStats(w, r, c, time.UTC, time.Sunday)
+}
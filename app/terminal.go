@@ -0,0 +1,93 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+	"github.com/mateusz834/charts/render"
+)
+
+// wantsText reports whether the client prefers a text response over the HTML page,
+// that is the case for curl (by its User-Agent) and for clients that accept
+// text/plain, but not text/html.
+func wantsText(r *http.Request) bool {
+	if strings.HasPrefix(r.UserAgent(), "curl/") {
+		return true
+	}
+
+	plain, html := false, false
+	for _, v := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(v))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/plain":
+			plain = true
+		case "text/html", "*/*", "text/*":
+			html = true
+		}
+	}
+	return plain && !html
+}
+
+// terminalOptions parses the text rendering options from url query params:
+// - "ascii" -> "1" uses only ASCII characters.
+// - "color" -> "0" disables ANSI colors.
+// - "labels" -> "0" disables month headers and weekday labels.
+// - "theme" -> "light" (default) or "dark", colors of the levels.
+// - "week_start" -> "sunday" or "monday", defaults to weekStart.
+func terminalOptions(query url.Values, weekStart time.Weekday) (render.TerminalOptions, error) {
+	o := render.DefaultTerminalOptions()
+	o.WeekStart = weekStart
+	o.ASCII = query.Get("ascii") == "1"
+	if query.Get("color") == "0" {
+		o.Colors = false
+	}
+	if query.Get("labels") == "0" {
+		o.Labels = false
+	}
+
+	switch query.Get("theme") {
+	case "", "light":
+	case "dark":
+		o.Theme = render.DarkTheme
+	default:
+		return o, errors.New("unknown theme")
+	}
+
+	if v := query.Get("week_start"); v != "" {
+		var err error
+		o.WeekStart, err = chart.ParseWeekStart(v)
+		if err != nil {
+			return o, err
+		}
+	}
+
+	return o, nil
+}
+
+// shareText sends the share rendered as text for terminals (see terminalOptions).
+func (a *application) shareText(w http.ResponseWriter, r *http.Request, path string) error {
+	share, err := a.getShare(path)
+	if err != nil {
+		return err
+	}
+
+	o, err := terminalOptions(r.URL.Query(), share.WeekStart)
+	if err != nil {
+		return &httpError{ResponseCode: http.StatusBadRequest, DebugErr: err}
+	}
+
+	var buf bytes.Buffer
+	if err := render.Terminal(&buf, share.Chart, &o); err != nil {
+		return err
+	}
+	return sendBuffered(w, "text/plain; charset=utf-8", &buf)
+}
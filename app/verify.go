@@ -0,0 +1,94 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+	"github.com/mateusz834/charts/service"
+)
+
+const maxVerifyLogSize = 4 << 20
+
+// verifyHistory compares the commit dates from the request body (git log --format=%aI)
+// with the chart from the "c" query param, or with the share from the "share" query param.
+func (a *application) verifyHistory(w http.ResponseWriter, r *http.Request) error {
+	sendErr := func(errType, msg string) error {
+		return sendJSON(w, http.StatusOK, chartErrResponse{ErrorType: errType, ErrorMsg: msg})
+	}
+
+	query := r.URL.Query()
+
+	var (
+		c   *chart.Chart
+		loc = time.UTC
+		err error
+	)
+	if path := query.Get("share"); path != "" {
+		share, err := a.publicSharesService.GetShare(path)
+		if err != nil {
+			if errors.Is(err, service.ErrNotFound) {
+				return sendErr("chart", "share not found")
+			}
+			return err
+		}
+		c, loc = share.Chart, share.Location()
+	} else if c, err = chart.Decode(query.Get("c")); err != nil {
+		return sendErr("chart", "invalid chart encoding")
+	}
+
+	if v := query.Get("tz"); v != "" {
+		if loc, err = chart.ParseLocation(v); err != nil {
+			return sendErr("timezone", err.Error())
+		}
+	}
+
+	commits, err := chart.ParseCommitDates(http.MaxBytesReader(w, r.Body, maxVerifyLogSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return sendErr("log", "log is too big")
+		}
+		return sendErr("log", err.Error())
+	}
+
+	v := chart.Verify(c, commits, loc)
+
+	type timezoneMismatch struct {
+		Commit   string `json:"commit"`
+		Day      string `json:"day"`
+		Intended string `json:"intended"`
+	}
+
+	dates := func(days []time.Time) []string {
+		res := make([]string, len(days))
+		for i, v := range days {
+			res[i] = v.Format(time.DateOnly)
+		}
+		return res
+	}
+
+	wrongTimezone := make([]timezoneMismatch, len(v.WrongTimezone))
+	for i, v := range v.WrongTimezone {
+		wrongTimezone[i] = timezoneMismatch{
+			Commit:   v.Commit.Format(time.RFC3339),
+			Day:      v.Day.Format(time.DateOnly),
+			Intended: v.Intended.Format(time.DateOnly),
+		}
+	}
+
+	return sendJSON(w, http.StatusOK, struct {
+		OK            bool               `json:"ok"`
+		Missing       []string           `json:"missing"`
+		Unexpected    []string           `json:"unexpected"`
+		WrongTimezone []timezoneMismatch `json:"wrong_timezone"`
+		Outside       int                `json:"outside"`
+	}{
+		OK:            v.OK(),
+		Missing:       dates(v.Missing),
+		Unexpected:    dates(v.Unexpected),
+		WrongTimezone: wrongTimezone,
+		Outside:       v.Outside,
+	})
+}
@@ -0,0 +1,233 @@
+package chart
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// maxDaysInYear is the number of days in a leap year.
+const maxDaysInYear = 366
+
+// MaxRangeWeeks is the maximum number of weeks of a range chart,
+// enough for two years, even when they are not aligned with weeks.
+const MaxRangeWeeks = 106
+
+var (
+	errInvalidYear     = errors.New("year out of range")
+	errInvalidRange    = errors.New("chart range out of range")
+	errInvalidLevel    = errors.New("intensity level out of range")
+	errDayOutsideChart = errors.New("day is outside of the chart")
+)
+
+// Level is an intensity level of a day, the same as the shades
+// on the github contribution calendar. Zero means that there
+// is no contribution on that day.
+type Level uint8
+
+// MaxLevel is the highest (darkest) intensity level.
+const MaxLevel Level = 4
+
+// Chart is a continuous range of days, where every day has an intensity Level.
+// It either covers a single calendar year (see New), or an arbitrary number of
+// weeks starting on any day (see NewRange), like the rolling "last 12 months"
+// calendar of github profiles.
+//
+// Days are identified by their calendar date (year, month, day), the
+// location and the time of day of the passed time.Time values are ignored,
+// so a chart means the same days in every timezone.
+type Chart struct {
+	// start is the first day of the chart, as a midnight UTC time.
+	start time.Time
+
+	// isRange is false for charts of a single calendar year.
+	isRange bool
+
+	levels []Level
+}
+
+// New creates an empty chart for the specified year.
+func New(year int) (*Chart, error) {
+	if year < 0 || year > math.MaxUint16 {
+		return nil, errInvalidYear
+	}
+	return &Chart{
+		start:  time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC),
+		levels: make([]Level, daysInYear(year)),
+	}, nil
+}
+
+// NewRange creates an empty chart of weeks*7 days, starting on the calendar
+// date of start. The weeks must be in range 1 to MaxRangeWeeks.
+func NewRange(start time.Time, weeks int) (*Chart, error) {
+	if weeks < 1 || weeks > MaxRangeWeeks {
+		return nil, errInvalidRange
+	}
+	start = calendarDate(start)
+	end := start.AddDate(0, 0, weeks*7-1)
+	if start.Year() < 0 || end.Year() > math.MaxUint16 {
+		return nil, errInvalidRange
+	}
+	return &Chart{
+		start:   start,
+		isRange: true,
+		levels:  make([]Level, weeks*7),
+	}, nil
+}
+
+// calendarDate returns the calendar date of t as a midnight UTC time.
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// IsRange reports whether the chart was created by NewRange.
+func (c *Chart) IsRange() bool {
+	return c.isRange
+}
+
+// Year returns the calendar year of the chart, for range
+// charts it is the year of the first day.
+func (c *Chart) Year() int {
+	return c.start.Year()
+}
+
+// Start returns the first day of the chart, as a midnight UTC time.
+func (c *Chart) Start() time.Time {
+	return c.start
+}
+
+// End returns the last day of the chart, as a midnight UTC time.
+func (c *Chart) End() time.Time {
+	return c.Day(c.Len() - 1)
+}
+
+// Len returns the number of days of the chart, that is 365 or 366
+// for year charts and a multiple of 7 for range charts.
+func (c *Chart) Len() int {
+	return len(c.levels)
+}
+
+// Weeks returns the number of weeks of a range chart, for year
+// charts it returns 0.
+func (c *Chart) Weeks() int {
+	if !c.isRange {
+		return 0
+	}
+	return len(c.levels) / 7
+}
+
+func daysInYear(year int) int {
+	return time.Date(year+1, time.January, 0, 0, 0, 0, 0, time.UTC).YearDay()
+}
+
+// dayIndex returns the zero-based index of day in the chart,
+// ok is false when the day does not belong to the chart.
+func (c *Chart) dayIndex(day time.Time) (int, bool) {
+	// Durations saturate, so days very far away from the
+	// start are still outside of the chart.
+	i := int(calendarDate(day).Sub(c.start) / (24 * time.Hour))
+	if i < 0 || i >= len(c.levels) {
+		return 0, false
+	}
+	return i, true
+}
+
+// Level returns the intensity level of the day, it returns 0 for
+// days that are outside of the chart.
+func (c *Chart) Level(day time.Time) Level {
+	i, ok := c.dayIndex(day)
+	if !ok {
+		return 0
+	}
+	return c.levels[i]
+}
+
+// SetLevel sets the intensity level of the day.
+func (c *Chart) SetLevel(day time.Time, level Level) error {
+	if level > MaxLevel {
+		return errInvalidLevel
+	}
+	i, ok := c.dayIndex(day)
+	if !ok {
+		return errDayOutsideChart
+	}
+	c.levels[i] = level
+	return nil
+}
+
+// IsSet reports whether the day has a non-zero level, it returns
+// false for days that are outside of the chart.
+func (c *Chart) IsSet(day time.Time) bool {
+	return c.Level(day) != 0
+}
+
+// Set sets the day to MaxLevel.
+func (c *Chart) Set(day time.Time) error {
+	return c.SetLevel(day, MaxLevel)
+}
+
+// Clear sets the day to level 0.
+func (c *Chart) Clear(day time.Time) error {
+	return c.SetLevel(day, 0)
+}
+
+// Day returns the i-th (zero-based) day of the chart as a midnight UTC time.
+func (c *Chart) Day(i int) time.Time {
+	return c.start.AddDate(0, 0, i)
+}
+
+// Days returns all set days in chronological order, as midnight UTC times.
+func (c *Chart) Days() []time.Time {
+	days := make([]time.Time, 0, c.Count())
+	for i, v := range c.levels {
+		if v != 0 {
+			days = append(days, c.Day(i))
+		}
+	}
+	return days
+}
+
+// Count returns the number of set days.
+func (c *Chart) Count() int {
+	count := 0
+	for _, v := range c.levels {
+		if v != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// Equal reports whether both charts cover the same days, with the same levels.
+func (c *Chart) Equal(o *Chart) bool {
+	if !c.sameDays(o) {
+		return false
+	}
+	for i, v := range c.levels {
+		if o.levels[i] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// sameDays reports whether both charts cover the same days.
+func (c *Chart) sameDays(o *Chart) bool {
+	return c.isRange == o.isRange && c.start.Equal(o.start) && len(c.levels) == len(o.levels)
+}
+
+// empty returns an empty chart that covers the same days as c.
+func (c *Chart) empty() *Chart {
+	return &Chart{start: c.start, isRange: c.isRange, levels: make([]Level, len(c.levels))}
+}
+
+// hasIntermediateLevels reports whether any day has a level
+// other than 0 or MaxLevel.
+func (c *Chart) hasIntermediateLevels() bool {
+	for _, v := range c.levels {
+		if v != 0 && v != MaxLevel {
+			return true
+		}
+	}
+	return false
+}
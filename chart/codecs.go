@@ -0,0 +1,224 @@
+package chart
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Built-in encoding versions.
+const (
+	// VersionBits: 2B big-endian year, followed by one bit per day (most
+	// significant bit first), days are either set (MaxLevel) or not.
+	VersionBits Version = '0'
+
+	// VersionLevels: 2B big-endian year, followed by four bits per day
+	// (high nibble first), each holding the intensity Level of the day.
+	VersionLevels Version = '1'
+
+	// VersionRangeBits: range charts (see NewRange), 2B big-endian year and 2B
+	// big-endian zero-based day of year of the first day, 1B number of weeks,
+	// followed by days encoded the same as in VersionBits.
+	VersionRangeBits Version = '2'
+
+	// VersionRangeLevels: the header of VersionRangeBits, followed
+	// by days encoded the same as in VersionLevels.
+	VersionRangeLevels Version = '3'
+)
+
+const (
+	yearHeaderLen  = 2
+	rangeHeaderLen = 5
+
+	maxRangeDays = MaxRangeWeeks * 7
+
+	// 2B for year 46B for days of a year (366/8).
+	bitsLen = yearHeaderLen + (maxDaysInYear+7)/8
+
+	// 2B for year 183B for days of a year (366/2).
+	levelsLen = yearHeaderLen + (maxDaysInYear+1)/2
+
+	rangeBitsLen   = rangeHeaderLen + (maxRangeDays+7)/8
+	rangeLevelsLen = rangeHeaderLen + (maxRangeDays+1)/2
+)
+
+var (
+	bitsCodec        = &daysCodec{header: yearHeader{}}
+	levelsCodec      = &daysCodec{header: yearHeader{}, levels: true}
+	rangeBitsCodec   = &daysCodec{header: rangeHeader{}}
+	rangeLevelsCodec = &daysCodec{header: rangeHeader{}, levels: true}
+)
+
+func init() {
+	RegisterCodec(VersionBits, bitsCodec)
+	RegisterCodec(VersionLevels, levelsCodec)
+	RegisterCodec(VersionRangeBits, rangeBitsCodec)
+	RegisterCodec(VersionRangeLevels, rangeLevelsCodec)
+}
+
+// header is the beginning of the payload, that describes which days
+// are covered by the chart.
+type header interface {
+	len() int
+	maxDays() int
+
+	// encode writes the header of c into raw, it returns
+	// ErrLossy when c cannot be described by this header.
+	encode(raw []byte, c *Chart) error
+
+	// decode returns an empty chart described by the header.
+	decode(raw []byte) (*Chart, error)
+}
+
+type yearHeader struct{}
+
+func (yearHeader) len() int     { return yearHeaderLen }
+func (yearHeader) maxDays() int { return maxDaysInYear }
+
+func (yearHeader) encode(raw []byte, c *Chart) error {
+	if c.isRange {
+		return ErrLossy
+	}
+	binary.BigEndian.PutUint16(raw, uint16(c.Year()))
+	return nil
+}
+
+func (yearHeader) decode(raw []byte) (*Chart, error) {
+	return New(int(binary.BigEndian.Uint16(raw)))
+}
+
+type rangeHeader struct{}
+
+func (rangeHeader) len() int     { return rangeHeaderLen }
+func (rangeHeader) maxDays() int { return maxRangeDays }
+
+func (rangeHeader) encode(raw []byte, c *Chart) error {
+	if !c.isRange {
+		return ErrLossy
+	}
+	binary.BigEndian.PutUint16(raw[0:2], uint16(c.start.Year()))
+	binary.BigEndian.PutUint16(raw[2:4], uint16(c.start.YearDay()-1))
+	raw[4] = byte(c.Weeks())
+	return nil
+}
+
+func (rangeHeader) decode(raw []byte) (*Chart, error) {
+	year := int(binary.BigEndian.Uint16(raw[0:2]))
+	day := int(binary.BigEndian.Uint16(raw[2:4]))
+	if day >= daysInYear(year) {
+		return nil, errInvaldChartEndoding
+	}
+	c, err := NewRange(time.Date(year, time.January, 1+day, 0, 0, 0, 0, time.UTC), int(raw[4]))
+	if err != nil {
+		return nil, errInvaldChartEndoding
+	}
+	return c, nil
+}
+
+// daysCodec encodes a header followed by either one bit (days are set
+// to MaxLevel or not) or four bits (intensity level) per day.
+type daysCodec struct {
+	header header
+	levels bool
+}
+
+func (d *daysCodec) MaxLen() int {
+	if d.levels {
+		return d.header.len() + (d.header.maxDays()+1)/2
+	}
+	return d.header.len() + (d.header.maxDays()+7)/8
+}
+
+func (d *daysCodec) Encode(c *Chart) ([]byte, error) {
+	if !d.levels && c.hasIntermediateLevels() {
+		return nil, ErrLossy
+	}
+	raw := make([]byte, d.MaxLen())
+	if err := d.header.encode(raw, c); err != nil {
+		return nil, err
+	}
+	days := raw[d.header.len():]
+	for i, v := range c.levels {
+		if d.levels {
+			days[i/2] |= byte(v) << (4 - 4*(i%2))
+		} else if v != 0 {
+			days[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return raw, nil
+}
+
+func (d *daysCodec) Decode(raw []byte) (*Chart, error) {
+	if len(raw) <= d.header.len() || len(raw) > d.MaxLen() {
+		return nil, errInvaldChartEndoding
+	}
+	c, err := d.header.decode(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	days := raw[d.header.len():]
+	perByte := 8
+	if d.levels {
+		perByte = 2
+	}
+	for i := 0; i < len(days)*perByte; i++ {
+		var level Level
+		if d.levels {
+			level = Level(days[i/2]>>(4-4*(i%2))) & 0xf
+		} else if days[i/8]&(1<<(7-i%8)) != 0 {
+			level = MaxLevel
+		}
+		if level == 0 {
+			continue
+		}
+		// Require that all trailing bits (above the number of days of
+		// the chart) are set to 0 and that levels are in range.
+		if i >= c.Len() || level > MaxLevel {
+			return nil, errInvaldChartEndoding
+		}
+		c.levels[i] = level
+	}
+	return c, nil
+}
+
+// MarshalBinary encodes the chart in a fixed-size binary form. Year charts
+// that only use levels 0 and MaxLevel are encoded in the 48 bytes long payload
+// of VersionBits, other year charts in the 185 bytes long payload of VersionLevels,
+// in both cases without removing trailing zeros. Range charts are encoded
+// the same way, using VersionRangeBits and VersionRangeLevels.
+func (c *Chart) MarshalBinary() ([]byte, error) {
+	switch {
+	case c.isRange && c.hasIntermediateLevels():
+		return rangeLevelsCodec.Encode(c)
+	case c.isRange:
+		return rangeBitsCodec.Encode(c)
+	case c.hasIntermediateLevels():
+		return levelsCodec.Encode(c)
+	default:
+		return bitsCodec.Encode(c)
+	}
+}
+
+// UnmarshalBinary decodes the binary form produced by MarshalBinary.
+func (c *Chart) UnmarshalBinary(raw []byte) error {
+	var codec *daysCodec
+	switch len(raw) {
+	case bitsLen:
+		codec = bitsCodec
+	case levelsLen:
+		codec = levelsCodec
+	case rangeBitsLen:
+		codec = rangeBitsCodec
+	case rangeLevelsLen:
+		codec = rangeLevelsCodec
+	default:
+		return errInvaldChartEndoding
+	}
+
+	dec, err := codec.Decode(raw)
+	if err != nil {
+		return err
+	}
+	*c = *dec
+	return nil
+}
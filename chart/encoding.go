@@ -2,84 +2,198 @@ package chart
 
 import (
 	"encoding/base64"
-	"encoding/binary"
 	"errors"
-	"math/bits"
+	"fmt"
+	"sort"
 	"strings"
-	"time"
+	"sync"
 )
 
-// 2B for year 46B for days of a year (366/8).
-const maxLen = 2 + 46
+// Charts are encoded as a version character followed by a base64 (url-safe,
+// without padding) encoded binary payload of that version, with trailing zero
+// bytes removed, so that there is exactly one valid encoding of every chart
+// in every version. The payload format of each version is defined by a Codec
+// registered for the version with RegisterCodec.
+
+// Version is the first character of a chart encoding.
+type Version byte
+
+func (v Version) String() string {
+	return string(v)
+}
+
+// Codec converts charts to and from the binary payload of a single encoding version.
+type Codec interface {
+	// MaxLen returns the maximum length of the payload.
+	MaxLen() int
+
+	// Encode returns the payload of the chart, trailing zero bytes are
+	// removed by the caller. It returns ErrLossy when the chart cannot
+	// be represented by this version without loosing information.
+	Encode(c *Chart) ([]byte, error)
+
+	// Decode decodes the payload (without trailing zero bytes, at most MaxLen
+	// bytes long). It must reject all payloads that Encode cannot produce.
+	Decode(raw []byte) (*Chart, error)
+}
 
 var (
 	errInvaldChartEndoding = errors.New("invalid chart encoding")
+	errEmptyChart          = errors.New("chart does not have any day set")
+
+	// ErrUnknownVersion is returned for versions without a registered Codec.
+	ErrUnknownVersion = errors.New("unknown chart encoding version")
+
+	// ErrLossy is returned when a chart cannot be encoded in the requested
+	// version without loosing information.
+	ErrLossy = errors.New("chart cannot be represented in this encoding version")
 )
 
-func Decode(enc string) ([]byte, error) {
-	if len(enc) == 0 || enc[0] != '0' {
-		return nil, errInvaldChartEndoding
+var (
+	codecsMu sync.RWMutex
+	codecs   = make(map[Version]Codec)
+)
+
+// RegisterCodec makes a codec available for the version.
+// It panics when the version is already registered.
+func RegisterCodec(version Version, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if _, ok := codecs[version]; ok {
+		panic(fmt.Sprintf("chart: codec for version %q already registered", version))
 	}
+	codecs[version] = codec
+}
 
-	enc = enc[1:]
+// Versions returns all registered versions in ascending order.
+func Versions() []Version {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	versions := make([]Version, 0, len(codecs))
+	for v := range codecs {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+func getCodec(version Version) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[version]
+	if !ok {
+		return nil, ErrUnknownVersion
+	}
+	return codec, nil
+}
+
+// Decode decodes a chart encoded in any of the registered versions.
+func Decode(enc string) (*Chart, error) {
+	c, _, err := DecodeVersion(enc)
+	return c, err
+}
 
-	if base64.RawURLEncoding.DecodedLen(len(enc)) > maxLen {
-		return nil, errInvaldChartEndoding
+// DecodeVersion is like Decode, but it also returns the version of the encoding.
+func DecodeVersion(enc string) (*Chart, Version, error) {
+	if len(enc) == 0 {
+		return nil, 0, errInvaldChartEndoding
+	}
+
+	version := Version(enc[0])
+	codec, err := getCodec(version)
+	if err != nil {
+		return nil, 0, errInvaldChartEndoding
+	}
+
+	enc = enc[1:]
+	if base64.RawURLEncoding.DecodedLen(len(enc)) > codec.MaxLen() {
+		return nil, 0, errInvaldChartEndoding
 	}
 
 	// Base64 decoder ignores \r and \n chars.
 	if strings.ContainsAny(enc, "\r\n") {
-		return nil, errInvaldChartEndoding
+		return nil, 0, errInvaldChartEndoding
 	}
 
-	raw := make([]byte, maxLen)
-	n, err := base64.RawURLEncoding.Decode(raw, []byte(enc))
-	if err != nil || n < 3 {
-		return nil, errInvaldChartEndoding
+	raw, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil || len(raw) == 0 {
+		return nil, 0, errInvaldChartEndoding
 	}
 
-	year := binary.BigEndian.Uint16(raw[:2])
+	// Require that the encodng does not have trailing zeros, so that
+	// there is exacly one valid representation.
+	if raw[len(raw)-1] == 0 {
+		return nil, 0, errInvaldChartEndoding
+	}
 
-	if raw := raw[:n]; len(raw) == maxLen {
-		// Require that all trailing bits (above 365 or 366 days) are set to 0.
-		daysInYear := time.Date(int(year)+1, time.January, 0, 0, 0, 0, 0, time.Local).YearDay()
-		if bits.TrailingZeros8(raw[len(raw)-1]) < 8-daysInYear%8 {
-			return nil, errInvaldChartEndoding
-		}
-	} else {
-		// Require that the encodng does not have trailing zeros, so that
-		// there is exacly one valid representation.
-		if raw[len(raw)-1] == 0 {
-			return nil, errInvaldChartEndoding
-		}
+	c, err := codec.Decode(raw)
+	if err != nil {
+		return nil, 0, err
 	}
+	return c, version, nil
+}
 
-	nonZero := false
-	for _, v := range raw[2:n] {
-		if v != 0 {
-			nonZero = true
-			break
+// Encode encodes the chart using the lowest registered version
+// that can represent it without loosing information.
+func Encode(c *Chart) (string, error) {
+	for _, v := range Versions() {
+		enc, err := EncodeVersion(c, v)
+		if errors.Is(err, ErrLossy) {
+			continue
 		}
+		return enc, err
 	}
+	return "", ErrLossy
+}
 
-	// Require that at least one day bit is set to 1.
-	if !nonZero {
-		return nil, errInvaldChartEndoding
+// EncodeVersion encodes the chart using the specified version.
+func EncodeVersion(c *Chart, version Version) (string, error) {
+	codec, err := getCodec(version)
+	if err != nil {
+		return "", err
 	}
 
-	return raw, nil
+	if c.Count() == 0 {
+		return "", errEmptyChart
+	}
+
+	raw, err := codec.Encode(c)
+	if err != nil {
+		return "", err
+	}
+
+	n := len(raw)
+	for n > 0 && raw[n-1] == 0 {
+		n--
+	}
+	return version.String() + base64.RawURLEncoding.EncodeToString(raw[:n]), nil
 }
 
-func Encode(chart []byte) (string, error) {
-	if len(chart) != maxLen {
-		return "", errInvaldChartEndoding
+// Transcode converts the encoded chart to the specified version, it
+// returns ErrLossy when the conversion would loose information.
+func Transcode(enc string, to Version) (string, error) {
+	c, err := Decode(enc)
+	if err != nil {
+		return "", err
 	}
-	n := len(chart) - 1
-	for i := n; i >= 0; i-- {
-		if chart[i] != 0 {
-			n = i + 1
-			break
-		}
+	return EncodeVersion(c, to)
+}
+
+// MarshalText returns the same encoding as Encode.
+func (c *Chart) MarshalText() ([]byte, error) {
+	enc, err := Encode(c)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(enc), nil
+}
+
+// UnmarshalText decodes the encoding accepted by Decode.
+func (c *Chart) UnmarshalText(text []byte) error {
+	dec, err := Decode(string(text))
+	if err != nil {
+		return err
 	}
-	return "0" + base64.RawURLEncoding.EncodeToString(chart[:n]), nil
+	*c = *dec
+	return nil
 }
@@ -1,7 +1,16 @@
 package chart
 
-/*
-func TestValidateChartEncoding(t *testing.T) {
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
 	b64 := base64.RawURLEncoding.EncodeToString
 	normalYearBytes := binary.BigEndian.AppendUint16(make([]byte, 0, 2), 2023)
 	leapYearBytes := binary.BigEndian.AppendUint16(make([]byte, 0, 2), 2024)
@@ -26,6 +35,15 @@ func TestValidateChartEncoding(t *testing.T) {
 		onesAfter366DaysNormalYear = b64(append(leapYearBytes,
 			append(bytes.Repeat([]byte{141}, 45), 0b11111110)...,
 		))
+
+		levelsMax365Year = b64(append(normalYearBytes, append(bytes.Repeat([]byte{0x12}, 182), 0x30)...))
+		levelsMax366Year = b64(append(leapYearBytes, bytes.Repeat([]byte{0x34}, 183)...))
+
+		levelAfter365DaysNormalYear = b64(append(normalYearBytes, append(bytes.Repeat([]byte{0x12}, 182), 0x31)...))
+		levelAboveMax               = b64(append(normalYearBytes, 0x50))
+		levelsTrailingZero          = b64(append(normalYearBytes, 0x12, 0x00))
+		levelsFullLastByteZero      = b64(append(normalYearBytes, append(bytes.Repeat([]byte{0x12}, 182), 0x00)...))
+		bitsFullLastByteZero        = b64(append(normalYearBytes, append(bytes.Repeat([]byte{141}, 45), 0)...))
 	)
 
 	var tests = []struct {
@@ -44,6 +62,18 @@ func TestValidateChartEncoding(t *testing.T) {
 		{encoded: "0" + leadingZeroByte, valid: false},
 		{encoded: "0" + onesAfter365DaysNormalYear, valid: false},
 		{encoded: "0" + onesAfter366DaysNormalYear, valid: false},
+		{encoded: "0" + bitsFullLastByteZero, valid: false},
+
+		{encoded: "1" + b64(append(normalYearBytes, 0x14)), valid: true},
+		{encoded: "1" + b64(append(normalYearBytes, 0x00, 0x02)), valid: true},
+		{encoded: "1" + levelsMax365Year, valid: true},
+		{encoded: "1" + levelsMax366Year, valid: true},
+
+		{encoded: "1" + levelAfter365DaysNormalYear, valid: false},
+		{encoded: "1" + levelAboveMax, valid: false},
+		{encoded: "1" + levelsTrailingZero, valid: false},
+		{encoded: "1" + levelsFullLastByteZero, valid: false},
+		{encoded: "1" + b64(normalYearBytes), valid: false},
 
 		{encoded: "0B-ff5m55EQgEAAAgQAAIEECBAgQECAgwEKAiQAgAACXMACAg0IgNAcBoR8CAAt-4", valid: true},
 		{encoded: "0B-cAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAI", valid: true},
@@ -57,9 +87,373 @@ func TestValidateChartEncoding(t *testing.T) {
 	}
 
 	for i, v := range tests {
-		if ValidateChartEncoding([]byte(v.encoded)) != v.valid {
+		c, err := Decode(v.encoded)
+		if (err == nil) != v.valid {
 			t.Errorf("%v: '%v' unexpected: %v", i, v.encoded, !v.valid)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		enc, err := Encode(c)
+		if err != nil {
+			t.Errorf("%v: '%v' failed to encode: %v", i, v.encoded, err)
+			continue
+		}
+		if enc != v.encoded {
+			t.Errorf("%v: Encode(Decode(%q)) = %q", i, v.encoded, enc)
+		}
+	}
+}
+
+func TestChartSetClear(t *testing.T) {
+	c, err := New(2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	days := []time.Time{
+		time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.February, 29, 23, 59, 0, 0, time.UTC),
+		time.Date(2024, time.December, 31, 12, 0, 0, 0, time.UTC),
+	}
+
+	for _, v := range days {
+		if err := c.Set(v); err != nil {
+			t.Fatalf("Set(%v) = %v", v, err)
+		}
+	}
+
+	if err := c.Set(time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Set of a day outside of the chart year succeeded")
+	}
+
+	if c.Count() != len(days) {
+		t.Errorf("Count() = %v, want: %v", c.Count(), len(days))
+	}
+
+	got := c.Days()
+	if len(got) != len(days) {
+		t.Fatalf("Days() = %v", got)
+	}
+	for i, v := range days {
+		y, m, d := v.Date()
+		if gy, gm, gd := got[i].Date(); gy != y || gm != m || gd != d {
+			t.Errorf("Days()[%v] = %v, want: %v", i, got[i], v)
+		}
+		if !c.IsSet(v) {
+			t.Errorf("IsSet(%v) = false", v)
+		}
+	}
+
+	if err := c.Clear(days[1]); err != nil {
+		t.Fatal(err)
+	}
+	if c.IsSet(days[1]) {
+		t.Errorf("IsSet(%v) = true after Clear", days[1])
+	}
+
+	text, err := c.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec Chart
+	if err := dec.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if !dec.Equal(c) {
+		t.Errorf("UnmarshalText(MarshalText()) roundtrip mismatch")
+	}
+
+	if err := c.SetLevel(days[2], 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetLevel(days[2], MaxLevel+1); err == nil {
+		t.Errorf("SetLevel(%v) succeeded", MaxLevel+1)
+	}
+
+	enc, err := Encode(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc[0] != '1' {
+		t.Errorf("Encode() = %q, want version 1 encoding", enc)
+	}
+	dec2, err := Decode(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec2.Level(days[2]) != 2 || dec2.Level(days[0]) != MaxLevel {
+		t.Errorf("Decode(Encode()) roundtrip mismatch")
+	}
+
+	empty, err := New(2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Encode(empty); err == nil {
+		t.Error("Encode of an empty chart succeeded")
+	}
+}
+
+func TestTranscode(t *testing.T) {
+	c, err := New(2023)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2023, time.March, 3, 0, 0, 0, 0, time.UTC)
+	if err := c.Set(day); err != nil {
+		t.Fatal(err)
+	}
+
+	bits, err := EncodeVersion(c, VersionBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	levels, err := Transcode(bits, VersionLevels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec, version, err := DecodeVersion(levels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != VersionLevels {
+		t.Errorf("DecodeVersion(%q) version = %v, want: %v", levels, version, VersionLevels)
+	}
+	if !dec.Equal(c) {
+		t.Errorf("Transcode(%q, %v) changed the chart", bits, VersionLevels)
+	}
+
+	back, err := Transcode(levels, VersionBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back != bits {
+		t.Errorf("Transcode(%q, %v) = %q, want: %q", levels, VersionBits, back, bits)
+	}
+
+	if err := c.SetLevel(day, 1); err != nil {
+		t.Fatal(err)
+	}
+	enc, err := EncodeVersion(c, VersionLevels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Transcode(enc, VersionBits); !errors.Is(err, ErrLossy) {
+		t.Errorf("Transcode(%q, %v) = %v, want: %v", enc, VersionBits, err, ErrLossy)
+	}
+	if _, err := Transcode(enc, 'z'); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("Transcode(%q, 'z') = %v, want: %v", enc, err, ErrUnknownVersion)
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+		valid  bool
+	}{
+		{name: "UTC", offset: 0, valid: true},
+		{name: "+02:00", offset: 2 * 3600, valid: true},
+		{name: "-0530", offset: -(5*3600 + 30*60), valid: true},
+		{name: "+14:00", offset: 14 * 3600, valid: true},
+		{name: "", valid: false},
+		{name: "Local", valid: false},
+		{name: "+2", valid: false},
+		{name: "+15:00", valid: false},
+		{name: "+02:60", valid: false},
+		{name: "+0a:00", valid: false},
+		{name: "Not/AZone", valid: false},
+	}
+
+	for _, v := range tests {
+		loc, err := ParseLocation(v.name)
+		if (err == nil) != v.valid {
+			t.Errorf("ParseLocation(%q) = %v, valid: %v", v.name, err, v.valid)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		_, offset := time.Date(2023, time.January, 1, 0, 0, 0, 0, loc).Zone()
+		if offset != v.offset {
+			t.Errorf("ParseLocation(%q) offset = %v, want: %v", v.name, offset, v.offset)
+		}
+	}
+}
+
+func TestRangeChart(t *testing.T) {
+	start := time.Date(2023, time.October, 15, 0, 0, 0, 0, time.UTC)
+	c, err := NewRange(start.Add(13*time.Hour), 53)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.IsRange() || c.Len() != 53*7 || c.Weeks() != 53 || !c.Start().Equal(start) {
+		t.Fatalf("NewRange(%v, 53) = {IsRange: %v, Len: %v, Weeks: %v, Start: %v}", start, c.IsRange(), c.Len(), c.Weeks(), c.Start())
+	}
+	if end := time.Date(2024, time.October, 19, 0, 0, 0, 0, time.UTC); !c.End().Equal(end) {
+		t.Errorf("End() = %v, want: %v", c.End(), end)
+	}
+
+	for _, day := range []time.Time{start, date(2024, time.February, 29), c.End()} {
+		if err := c.Set(day); err != nil {
+			t.Fatalf("Set(%v) = %v", day, err)
+		}
+	}
+	for _, day := range []time.Time{start.AddDate(0, 0, -1), c.End().AddDate(0, 0, 1)} {
+		if err := c.Set(day); err == nil {
+			t.Errorf("Set(%v) of a day outside of the range succeeded", day)
+		}
+	}
+
+	for _, level := range []Level{MaxLevel, 2} {
+		if err := c.SetLevel(c.End(), level); err != nil {
+			t.Fatal(err)
+		}
+
+		enc, err := Encode(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec, version, err := DecodeVersion(enc)
+		if err != nil {
+			t.Fatalf("DecodeVersion(%q) = %v", enc, err)
+		}
+		want := VersionRangeBits
+		if level != MaxLevel {
+			want = VersionRangeLevels
+		}
+		if version != want {
+			t.Errorf("DecodeVersion(%q) version = %v, want: %v", enc, version, want)
+		}
+		if !dec.Equal(c) {
+			t.Errorf("Decode(Encode()) roundtrip mismatch")
+		}
+
+		bin, err := c.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var binDec Chart
+		if err := binDec.UnmarshalBinary(bin); err != nil {
+			t.Fatal(err)
+		}
+		if !binDec.Equal(c) {
+			t.Errorf("UnmarshalBinary(MarshalBinary()) roundtrip mismatch")
+		}
+	}
+
+	if _, err := EncodeVersion(c, VersionBits); !errors.Is(err, ErrLossy) {
+		t.Errorf("EncodeVersion(range, %v) = %v, want: %v", VersionBits, err, ErrLossy)
+	}
+
+	year, err := New(2023)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := year.Set(start); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EncodeVersion(year, VersionRangeBits); !errors.Is(err, ErrLossy) {
+		t.Errorf("EncodeVersion(year, %v) = %v, want: %v", VersionRangeBits, err, ErrLossy)
+	}
+
+	for _, weeks := range []int{0, -1, MaxRangeWeeks + 1} {
+		if _, err := NewRange(start, weeks); err == nil {
+			t.Errorf("NewRange(%v, %v) succeeded", start, weeks)
+		}
+	}
+}
+
+func TestDecodeRange(t *testing.T) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	header := func(year, day int, weeks byte) []byte {
+		h := binary.BigEndian.AppendUint16(nil, uint16(year))
+		h = binary.BigEndian.AppendUint16(h, uint16(day))
+		return append(h, weeks)
+	}
+
+	var tests = []struct {
+		encoded string
+		valid   bool
+	}{
+		{encoded: "2" + b64(append(header(2023, 0, 1), 0b11111110)), valid: true},
+		{encoded: "2" + b64(append(header(2023, 364, 1), 0b10000000)), valid: true},
+		{encoded: "2" + b64(append(header(2024, 365, 1), 0b10000000)), valid: true},
+		{encoded: "3" + b64(append(header(2023, 0, 1), 0x12, 0x34, 0x12, 0x30)), valid: true},
+		{encoded: "2" + b64(append(header(2023, 0, MaxRangeWeeks), append(make([]byte, MaxRangeWeeks*7/8), 0b11000000)...)), valid: true},
+
+		// Days after the last week.
+		{encoded: "2" + b64(append(header(2023, 0, 1), 0b11111111)), valid: false},
+		{encoded: "3" + b64(append(header(2023, 0, 1), 0x12, 0x34, 0x12, 0x31)), valid: false},
+		// Day of year out of range.
+		{encoded: "2" + b64(append(header(2023, 365, 1), 0b10000000)), valid: false},
+		// Weeks out of range.
+		{encoded: "2" + b64(append(header(2023, 0, 0), 0b10000000)), valid: false},
+		{encoded: "2" + b64(append(header(2023, 0, MaxRangeWeeks+1), 0b10000000)), valid: false},
+		// End of the range after the max year.
+		{encoded: "2" + b64(append(header(65535, 364, 1), 0b10000000)), valid: false},
+		// No days.
+		{encoded: "2" + b64(header(2023, 0, 1)), valid: false},
+		{encoded: "3" + b64(append(header(2023, 0, 1), 0x50)), valid: false},
+	}
+
+	for _, v := range tests {
+		_, err := Decode(v.encoded)
+		if (err == nil) != v.valid {
+			t.Errorf("Decode(%q) = %v, valid: %v", v.encoded, err, v.valid)
+		}
+	}
+}
+
+func TestFrames(t *testing.T) {
+	a := newTestChart(t, 2023, map[time.Time]Level{date(2023, time.January, 1): 4})
+	b := newTestChart(t, 2023, map[time.Time]Level{date(2023, time.January, 2): 2})
+
+	enc, err := EncodeFrames([]*Chart{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := DecodeFrames(enc)
+	if err != nil {
+		t.Fatalf("DecodeFrames(%q) = %v", enc, err)
+	}
+	if len(frames) != 2 || !frames[0].Equal(a) || !frames[1].Equal(b) {
+		t.Errorf("DecodeFrames(EncodeFrames()) roundtrip mismatch")
+	}
+
+	single, err := Encode(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frames, err := DecodeFrames(single); err != nil || len(frames) != 1 {
+		t.Errorf("DecodeFrames(%q) = (%v frames, %v), want: (1 frame, nil)", single, len(frames), err)
+	}
+
+	other := newTestChart(t, 2024, map[time.Time]Level{date(2024, time.January, 1): 4})
+	if _, err := EncodeFrames([]*Chart{a, other}); err == nil {
+		t.Error("EncodeFrames of charts of different years succeeded")
+	}
+	otherEnc, err := Encode(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tooMany := make([]string, MaxFrames+1)
+	for i := range tooMany {
+		tooMany[i] = single
+	}
+	for _, v := range []string{
+		"",
+		single + FrameSeparator,
+		single + FrameSeparator + otherEnc,
+		strings.Join(tooMany, FrameSeparator),
+	} {
+		if _, err := DecodeFrames(v); err == nil {
+			t.Errorf("DecodeFrames(%q) succeeded", v)
 		}
 	}
 }
-*/
@@ -0,0 +1,87 @@
+package chart
+
+import "unicode"
+
+// glyph is a 7 pixels high bitmap of a character, each
+// string is a single row, '#' marks a set pixel.
+type glyph [7]string
+
+// font is a 7 pixels high font, so that a single line of text fits
+// in the seven weekdays of the chart. Lower case letters are drawn
+// using the upper case glyphs.
+var font = map[rune]glyph{
+	' ':  {"...", "...", "...", "...", "...", "...", "..."},
+	'A':  {".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'B':  {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
+	'C':  {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
+	'D':  {"####.", "#...#", "#...#", "#...#", "#...#", "#...#", "####."},
+	'E':  {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+	'F':  {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
+	'G':  {".###.", "#...#", "#....", "#.###", "#...#", "#...#", ".####"},
+	'H':  {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'I':  {"###", ".#.", ".#.", ".#.", ".#.", ".#.", "###"},
+	'J':  {"..###", "...#.", "...#.", "...#.", "...#.", "#..#.", ".##.."},
+	'K':  {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
+	'L':  {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
+	'M':  {"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#"},
+	'N':  {"#...#", "#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#"},
+	'O':  {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'P':  {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
+	'Q':  {".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#"},
+	'R':  {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
+	'S':  {".####", "#....", "#....", ".###.", "....#", "....#", "####."},
+	'T':  {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	'U':  {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'V':  {"#...#", "#...#", "#...#", "#...#", "#...#", ".#.#.", "..#.."},
+	'W':  {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "#.#.#", ".#.#."},
+	'X':  {"#...#", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "#...#"},
+	'Y':  {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
+	'Z':  {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####"},
+	'0':  {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1':  {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2':  {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3':  {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4':  {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5':  {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6':  {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7':  {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8':  {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9':  {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+	'.':  {".", ".", ".", ".", ".", ".", "#"},
+	',':  {"..", "..", "..", "..", "..", ".#", "#."},
+	':':  {".", ".", "#", ".", "#", ".", "."},
+	';':  {"..", "..", ".#", "..", ".#", ".#", "#."},
+	'!':  {"#", "#", "#", "#", "#", ".", "#"},
+	'?':  {".###.", "#...#", "....#", "...#.", "..#..", ".....", "..#.."},
+	'\'': {"#", "#", ".", ".", ".", ".", "."},
+	'"':  {"#.#", "#.#", "...", "...", "...", "...", "..."},
+	'-':  {"...", "...", "...", "###", "...", "...", "..."},
+	'+':  {".....", "..#..", "..#..", "#####", "..#..", "..#..", "....."},
+	'=':  {"....", "....", "####", "....", "####", "....", "...."},
+	'_':  {".....", ".....", ".....", ".....", ".....", ".....", "#####"},
+	'/':  {"....#", "....#", "...#.", "..#..", ".#...", "#....", "#...."},
+	'(':  {"..#", ".#.", "#..", "#..", "#..", ".#.", "..#"},
+	')':  {"#..", ".#.", "..#", "..#", "..#", ".#.", "#.."},
+	'<':  {"...#", "..#.", ".#..", "#...", ".#..", "..#.", "...#"},
+	'>':  {"#...", ".#..", "..#.", "...#", "..#.", ".#..", "#..."},
+	'#':  {".#.#.", ".#.#.", "#####", ".#.#.", "#####", ".#.#.", ".#.#."},
+	'&':  {".##..", "#..#.", "#.#..", ".#...", "#.#.#", "#..#.", ".##.#"},
+	'@':  {".###.", "#...#", "#.###", "#.#.#", "#.###", "#....", ".####"},
+	'*':  {".....", "#.#.#", ".###.", "#####", ".###.", "#.#.#", "....."},
+	'♥':  {".....", ".#.#.", "#####", "#####", ".###.", "..#..", "....."},
+}
+
+// fontGlyph returns the glyph of the rune, ok is false when the font does not support it.
+func fontGlyph(r rune) (g glyph, ok bool) {
+	g, ok = font[unicode.ToUpper(r)]
+	return g, ok
+}
+
+// width returns the width of the glyph in pixels.
+func (g *glyph) width() int {
+	return len(g[0])
+}
+
+func (g *glyph) isSet(x, y int) bool {
+	return g[y][x] == '#'
+}
@@ -0,0 +1,73 @@
+package chart
+
+import (
+	"errors"
+	"strings"
+)
+
+// FrameSeparator separates encoded charts in an encoding of frames,
+// it is not used by the base64 (url-safe) alphabet.
+const FrameSeparator = "."
+
+// MaxFrames is the maximum number of frames of an animation.
+const MaxFrames = 32
+
+var (
+	errNoFrames        = errors.New("no frames")
+	errTooManyFrames   = errors.New("too many frames")
+	errFramesDaysMatch = errors.New("frames do not cover the same days")
+)
+
+// ValidateFrames reports whether the charts can be used as frames of an animation,
+// there must be 1 to MaxFrames frames and all of them must cover the same days.
+func ValidateFrames(frames []*Chart) error {
+	if len(frames) == 0 {
+		return errNoFrames
+	}
+	if len(frames) > MaxFrames {
+		return errTooManyFrames
+	}
+	for _, v := range frames[1:] {
+		if !v.sameDays(frames[0]) {
+			return errFramesDaysMatch
+		}
+	}
+	return nil
+}
+
+// EncodeFrames encodes the frames (see ValidateFrames) using Encode,
+// separated by FrameSeparator.
+func EncodeFrames(frames []*Chart) (string, error) {
+	if err := ValidateFrames(frames); err != nil {
+		return "", err
+	}
+	enc := make([]string, len(frames))
+	for i, v := range frames {
+		var err error
+		if enc[i], err = Encode(v); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(enc, FrameSeparator), nil
+}
+
+// DecodeFrames decodes frames encoded by EncodeFrames,
+// a single encoded chart is decoded as a single frame.
+func DecodeFrames(enc string) ([]*Chart, error) {
+	if strings.Count(enc, FrameSeparator) >= MaxFrames {
+		return nil, errTooManyFrames
+	}
+	parts := strings.Split(enc, FrameSeparator)
+	frames := make([]*Chart, len(parts))
+	for i, v := range parts {
+		c, err := Decode(v)
+		if err != nil {
+			return nil, err
+		}
+		frames[i] = c
+	}
+	if err := ValidateFrames(frames); err != nil {
+		return nil, err
+	}
+	return frames, nil
+}
@@ -0,0 +1,111 @@
+package chart
+
+import (
+	"errors"
+	"image"
+	"math"
+	"time"
+)
+
+// ImageOptions are the options of FromImage.
+type ImageOptions struct {
+	// Levels quantizes the image into all intensity levels,
+	// otherwise days are either not set or set to MaxLevel.
+	Levels bool
+
+	// Threshold is the darkness (0-1) at which a day is set,
+	// it is not used when Levels is true.
+	Threshold float64
+
+	// Invert sets days of the light (instead of dark) pixels.
+	Invert bool
+
+	// WeekStart is the first weekday of the chart layout (see NewLayout),
+	// the zero value is sunday (github).
+	WeekStart time.Weekday
+}
+
+// DefaultImageOptions returns options that set days of dark pixels.
+func DefaultImageOptions() ImageOptions {
+	return ImageOptions{Threshold: 0.5}
+}
+
+var errInvalidImageOptions = errors.New("invalid image options")
+
+// FromImage creates a chart of the year from the image. The image is scaled
+// (without preserving the aspect ratio) to the grid of weeks and weekdays of
+// the chart, every day is set based on the average darkness of the pixels
+// that it covers. Transparent pixels are treated as white.
+func FromImage(year int, img image.Image, o *ImageOptions) (*Chart, error) {
+	if o.Threshold < 0 || o.Threshold > 1 || math.IsNaN(o.Threshold) {
+		return nil, errInvalidImageOptions
+	}
+
+	c, err := New(year)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil, errInvalidImageOptions
+	}
+
+	l, err := c.Layout(o.WeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	weeks := l.Weeks()
+	for week := 0; week < weeks; week++ {
+		for row := 0; row < 7; row++ {
+			i, ok := l.Index(week, row)
+			if !ok {
+				continue
+			}
+
+			darkness := averageDarkness(img, cellRect(bounds, week, row, weeks, 7))
+			if o.Invert {
+				darkness = 1 - darkness
+			}
+
+			if o.Levels {
+				c.levels[i] = Level(math.Round(darkness * float64(MaxLevel)))
+			} else if darkness >= o.Threshold && darkness != 0 {
+				c.levels[i] = MaxLevel
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// cellRect returns the part of bounds that is covered by the cell (x, y) of a w×h grid,
+// it is never empty, so for images smaller than the grid, pixels are covered by many cells.
+func cellRect(bounds image.Rectangle, x, y, w, h int) image.Rectangle {
+	dx, dy := bounds.Dx(), bounds.Dy()
+	r := image.Rect(x*dx/w, y*dy/h, (x+1)*dx/w, (y+1)*dy/h)
+	if r.Dx() == 0 {
+		r.Max.X = r.Min.X + 1
+	}
+	if r.Dy() == 0 {
+		r.Max.Y = r.Min.Y + 1
+	}
+	return r.Add(bounds.Min)
+}
+
+// averageDarkness returns the average darkness (0 for white, 1 for black) of the pixels in r.
+func averageDarkness(img image.Image, r image.Rectangle) float64 {
+	var sum float64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			// Colors are alpha-premultiplied, so adding the missing alpha
+			// composes the pixel over a white background.
+			red, green, blue, alpha := img.At(x, y).RGBA()
+			white := 0xffff - alpha
+			lum := 0.299*float64(red+white) + 0.587*float64(green+white) + 0.114*float64(blue+white)
+			sum += 1 - lum/0xffff
+		}
+	}
+	return sum / float64(r.Dx()*r.Dy())
+}
@@ -0,0 +1,66 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+func TestFromImage(t *testing.T) {
+	// Left half black, right half white, smaller than the chart grid.
+	img := image.NewGray(image.Rect(10, 10, 12, 11))
+	img.SetGray(10, 10, color.Gray{Y: 0})
+	img.SetGray(11, 10, color.Gray{Y: 0xff})
+
+	o := DefaultImageOptions()
+	c, err := FromImage(2023, img, &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.IsSet(time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("first day is not set")
+	}
+	if c.IsSet(time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Error("last day is set")
+	}
+	// 2023 has 53 weeks, so the first 27 weeks cover the black pixel.
+	if c.Count() != 27*7 {
+		t.Errorf("Count() = %v, want: %v", c.Count(), 27*7)
+	}
+
+	o.Invert = true
+	c, err = FromImage(2023, img, &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Count() != 365-27*7 {
+		t.Errorf("inverted Count() = %v, want: %v", c.Count(), 365-27*7)
+	}
+
+	gray := image.NewUniform(color.Gray{Y: 0x80})
+	o = DefaultImageOptions()
+	o.Levels = true
+	c, err = FromImage(2023, image.NewRGBA(image.Rect(0, 0, 1, 1)), &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Count() != 0 {
+		t.Errorf("transparent image: Count() = %v, want: 0", c.Count())
+	}
+
+	uniform := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			uniform.Set(x, y, gray)
+		}
+	}
+	c, err = FromImage(2023, uniform, &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := c.Level(time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)); l != 2 {
+		t.Errorf("gray image: Level() = %v, want: 2", l)
+	}
+}
@@ -0,0 +1,94 @@
+package chart
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+var errInvalidWeekStart = errors.New("week must start on sunday or monday")
+
+// Layout maps the days of a chart to the calendar grid, where every
+// column is a week and every row is a weekday. The first and the last
+// week might be partial, the days of these weeks that are before (or
+// after) the chart are not part of the layout.
+type Layout struct {
+	weekStart time.Weekday
+	days      int
+
+	// offset is the number of days of the first week,
+	// that are before the first day of the chart.
+	offset int
+}
+
+// NewLayout returns the layout of the year with weeks starting on weekStart.
+// Github uses weeks starting on sunday, GitLab and Gitea on monday, other
+// week starts are not supported.
+func NewLayout(year int, weekStart time.Weekday) (Layout, error) {
+	if year < 0 || year > math.MaxUint16 {
+		return Layout{}, errInvalidYear
+	}
+	return newLayout(time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), daysInYear(year), weekStart)
+}
+
+func newLayout(start time.Time, days int, weekStart time.Weekday) (Layout, error) {
+	if weekStart != time.Sunday && weekStart != time.Monday {
+		return Layout{}, errInvalidWeekStart
+	}
+	return Layout{
+		weekStart: weekStart,
+		days:      days,
+		offset:    (int(start.Weekday()) - int(weekStart) + 7) % 7,
+	}, nil
+}
+
+// Layout returns the layout of the chart with weeks starting on weekStart (see NewLayout),
+// range charts that start on a different weekday have partial first and last weeks.
+func (c *Chart) Layout(weekStart time.Weekday) (Layout, error) {
+	return newLayout(c.start, c.Len(), weekStart)
+}
+
+// ParseWeekStart parses the "sunday" or "monday" (case-insensitive) week start.
+func ParseWeekStart(s string) (time.Weekday, error) {
+	switch strings.ToLower(s) {
+	case "sunday":
+		return time.Sunday, nil
+	case "monday":
+		return time.Monday, nil
+	}
+	return 0, errInvalidWeekStart
+}
+
+// WeekStart returns the first weekday of every week.
+func (l Layout) WeekStart() time.Weekday {
+	return l.weekStart
+}
+
+// Weeks returns the number of (partial) weeks.
+func (l Layout) Weeks() int {
+	return (l.days + l.offset + 6) / 7
+}
+
+// Position returns the week (column) and row of the i-th (zero-based) day.
+func (l Layout) Position(i int) (week, row int) {
+	return (i + l.offset) / 7, (i + l.offset) % 7
+}
+
+// Index returns the zero-based day at the week (column) and
+// row, ok is false for positions outside of the layout days.
+func (l Layout) Index(week, row int) (int, bool) {
+	if week < 0 || row < 0 || row > 6 {
+		return 0, false
+	}
+	i := week*7 + row - l.offset
+	if i < 0 || i >= l.days {
+		return 0, false
+	}
+	return i, true
+}
+
+// Row returns the row of the weekday.
+func (l Layout) Row(weekday time.Weekday) int {
+	return (int(weekday) - int(l.weekStart) + 7) % 7
+}
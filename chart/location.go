@@ -0,0 +1,64 @@
+package chart
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var errInvalidLocation = errors.New("invalid timezone")
+
+// ParseLocation parses a timezone, that is either an IANA timezone
+// name (e.g. "Europe/Warsaw", "UTC") or a fixed UTC offset in the
+// ±hh:mm or ±hhmm form (e.g. "+02:00", "-0530").
+//
+// Charts are defined in calendar days, the timezone only describes
+// where the days of the chart should be reproduced.
+func ParseLocation(name string) (*time.Location, error) {
+	if len(name) != 0 && (name[0] == '+' || name[0] == '-') {
+		offset, err := parseOffset(name)
+		if err != nil {
+			return nil, err
+		}
+		return time.FixedZone(name, offset), nil
+	}
+
+	// LoadLocation treats "" as UTC and "Local" as the server
+	// timezone, that should not leak into charts.
+	if name == "" || name == "Local" {
+		return nil, errInvalidLocation
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errInvalidLocation, name)
+	}
+	return loc, nil
+}
+
+// parseOffset parses the ±hh:mm or ±hhmm offset into seconds east of UTC.
+func parseOffset(s string) (int, error) {
+	sign, rest := s[0], s[1:]
+	if len(rest) == 5 && rest[2] == ':' {
+		rest = rest[:2] + rest[3:]
+	}
+	if len(rest) != 4 {
+		return 0, errInvalidLocation
+	}
+	for _, v := range rest {
+		if v < '0' || v > '9' {
+			return 0, errInvalidLocation
+		}
+	}
+	hours, _ := strconv.Atoi(rest[:2])
+	minutes, _ := strconv.Atoi(rest[2:])
+	if hours > 14 || minutes > 59 {
+		return 0, errInvalidLocation
+	}
+	offset := hours*3600 + minutes*60
+	if sign == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
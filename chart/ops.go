@@ -0,0 +1,152 @@
+package chart
+
+import (
+	"errors"
+	"time"
+)
+
+var errDaysMismatch = errors.New("charts do not cover the same days")
+
+// combine creates a new chart with levels computed by f from
+// the levels of the same days of both charts.
+func combine(a, b *Chart, f func(a, b Level) Level) (*Chart, error) {
+	if !a.sameDays(b) {
+		return nil, errDaysMismatch
+	}
+	res := a.empty()
+	for i := range res.levels {
+		res.levels[i] = f(a.levels[i], b.levels[i])
+	}
+	return res, nil
+}
+
+// Union returns a chart with days set in any of the charts,
+// using the higher level when both are set.
+func Union(a, b *Chart) (*Chart, error) {
+	return combine(a, b, func(a, b Level) Level {
+		if a > b {
+			return a
+		}
+		return b
+	})
+}
+
+// Intersection returns a chart with days set in both charts,
+// using the lower level of the two.
+func Intersection(a, b *Chart) (*Chart, error) {
+	return combine(a, b, func(a, b Level) Level {
+		if a < b {
+			return a
+		}
+		return b
+	})
+}
+
+// Difference returns a chart with days of a, that are not set in b.
+func Difference(a, b *Chart) (*Chart, error) {
+	return combine(a, b, func(a, b Level) Level {
+		if b != 0 {
+			return 0
+		}
+		return a
+	})
+}
+
+// Invert returns a chart with all not set days set to MaxLevel
+// and all set days cleared.
+func Invert(c *Chart) *Chart {
+	res := c.empty()
+	for i, v := range c.levels {
+		if v == 0 {
+			res.levels[i] = MaxLevel
+		}
+	}
+	return res
+}
+
+// Shift returns a chart with all days moved by n days (forward
+// for positive n), days moved outside of the chart are dropped.
+// Shifting by a multiple of 7 moves the chart by whole weeks.
+func Shift(c *Chart, n int) *Chart {
+	res := c.empty()
+	for i := range c.levels {
+		if j := i + n; j >= 0 && j < len(res.levels) {
+			res.levels[j] = c.levels[i]
+		}
+	}
+	return res
+}
+
+// transform sets the days of the empty chart res, by moving every day from
+// its (week, row) position in the layout of c, to the position returned by f
+// in the layout of res. Days moved outside of res are dropped.
+func transform(c, res *Chart, weekStart time.Weekday, f func(week, row int) (int, int)) (*Chart, error) {
+	from, err := c.Layout(weekStart)
+	if err != nil {
+		return nil, err
+	}
+	to, err := res.Layout(weekStart)
+	if err != nil {
+		return nil, err
+	}
+	for i := range c.levels {
+		if c.levels[i] == 0 {
+			continue
+		}
+		if j, ok := to.Index(f(from.Position(i))); ok {
+			res.levels[j] = c.levels[i]
+		}
+	}
+	return res, nil
+}
+
+// MirrorHorizontal returns the chart mirrored left to right (the
+// first week becomes the last one), days moved outside of the chart
+// (because of partial first and last weeks) are dropped.
+func MirrorHorizontal(c *Chart, weekStart time.Weekday) (*Chart, error) {
+	l, err := c.Layout(weekStart)
+	if err != nil {
+		return nil, err
+	}
+	weeks := l.Weeks()
+	return transform(c, c.empty(), weekStart, func(week, row int) (int, int) {
+		return weeks - 1 - week, row
+	})
+}
+
+// MirrorVertical returns the chart mirrored top to bottom (the first
+// weekday becomes the last one), days moved outside of the chart
+// (because of partial first and last weeks) are dropped.
+func MirrorVertical(c *Chart, weekStart time.Weekday) (*Chart, error) {
+	return transform(c, c.empty(), weekStart, func(week, row int) (int, int) {
+		return week, 6 - row
+	})
+}
+
+// Retarget returns the chart moved to another year, preserving the visual
+// layout (week and weekday) of every day, rather than its date.
+// Days that do not exist in the visual layout of the year are dropped.
+func Retarget(c *Chart, year int, weekStart time.Weekday) (*Chart, error) {
+	res, err := New(year)
+	if err != nil {
+		return nil, err
+	}
+	return retarget(c, res, weekStart)
+}
+
+// RetargetRange is like Retarget, but it moves the chart to the range of
+// weeks starting on start (see NewRange), e.g. to align a design with the
+// rolling calendar of github profiles.
+func RetargetRange(c *Chart, start time.Time, weeks int, weekStart time.Weekday) (*Chart, error) {
+	res, err := NewRange(start, weeks)
+	if err != nil {
+		return nil, err
+	}
+	return retarget(c, res, weekStart)
+}
+
+func retarget(c, res *Chart, weekStart time.Weekday) (*Chart, error) {
+	return transform(c, res, weekStart, func(week, row int) (int, int) {
+		return week, row
+	})
+}
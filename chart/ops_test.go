@@ -0,0 +1,233 @@
+package chart
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func newTestChart(t *testing.T, year int, days map[time.Time]Level) *Chart {
+	c, err := New(year)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for day, level := range days {
+		if err := c.SetLevel(day, level); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+func checkLevels(t *testing.T, name string, c *Chart, want map[time.Time]Level) {
+	t.Helper()
+	for i, n := 0, c.Len(); i < n; i++ {
+		day := c.Day(i)
+		if c.Level(day) != want[day] {
+			t.Errorf("%v: Level(%v) = %v, want: %v", name, day.Format(time.DateOnly), c.Level(day), want[day])
+		}
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	a := newTestChart(t, 2023, map[time.Time]Level{
+		date(2023, time.January, 1): 1,
+		date(2023, time.January, 2): 4,
+	})
+	b := newTestChart(t, 2023, map[time.Time]Level{
+		date(2023, time.January, 2): 2,
+		date(2023, time.January, 3): 3,
+	})
+
+	union, err := Union(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLevels(t, "Union", union, map[time.Time]Level{
+		date(2023, time.January, 1): 1,
+		date(2023, time.January, 2): 4,
+		date(2023, time.January, 3): 3,
+	})
+
+	intersection, err := Intersection(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLevels(t, "Intersection", intersection, map[time.Time]Level{
+		date(2023, time.January, 2): 2,
+	})
+
+	difference, err := Difference(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLevels(t, "Difference", difference, map[time.Time]Level{
+		date(2023, time.January, 1): 1,
+	})
+
+	if Invert(a).Count() != 365-2 {
+		t.Errorf("Invert(a).Count() = %v, want: %v", Invert(a).Count(), 365-2)
+	}
+
+	if _, err := Union(a, newTestChart(t, 2024, nil)); err == nil {
+		t.Error("Union of charts of different years succeeded")
+	}
+}
+
+func TestTransformations(t *testing.T) {
+	// 2023 starts on a sunday, 2024 on a monday.
+	c := newTestChart(t, 2023, map[time.Time]Level{
+		date(2023, time.January, 1):   4,
+		date(2023, time.January, 10):  2,
+		date(2023, time.December, 31): 1,
+	})
+
+	checkLevels(t, "Shift", Shift(c, 7), map[time.Time]Level{
+		date(2023, time.January, 8):  4,
+		date(2023, time.January, 17): 2,
+	})
+
+	checkLevels(t, "Shift", Shift(c, -1), map[time.Time]Level{
+		date(2023, time.January, 9):   2,
+		date(2023, time.December, 30): 1,
+	})
+
+	// 2023 has 53 weeks, week 0 starts on 2023-01-01 and the
+	// last week has only a single day (sunday).
+	mirrored, err := MirrorHorizontal(c, time.Sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLevels(t, "MirrorHorizontal", mirrored, map[time.Time]Level{
+		date(2023, time.December, 31): 4,
+		date(2023, time.December, 26): 2,
+		date(2023, time.January, 1):   1,
+	})
+
+	mirrored, err = MirrorVertical(c, time.Sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLevels(t, "MirrorVertical", mirrored, map[time.Time]Level{
+		date(2023, time.January, 7):  4,
+		date(2023, time.January, 12): 2,
+	})
+
+	retargeted, err := Retarget(c, 2024, time.Sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sunday of the first week of 2024 is in 2023.
+	checkLevels(t, "Retarget", retargeted, map[time.Time]Level{
+		date(2024, time.January, 9):   2,
+		date(2024, time.December, 29): 1,
+	})
+}
+
+func TestTransformationsMondayWeekStart(t *testing.T) {
+	// With weeks starting on monday, 2023-01-01 (sunday) is the
+	// last day of the first week, and 2024-01-01 the first one.
+	c := newTestChart(t, 2023, map[time.Time]Level{
+		date(2023, time.January, 1): 4,
+		date(2023, time.January, 2): 2,
+	})
+
+	mirrored, err := MirrorVertical(c, time.Monday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLevels(t, "MirrorVertical", mirrored, map[time.Time]Level{
+		date(2023, time.January, 8): 2,
+	})
+
+	retargeted, err := Retarget(c, 2024, time.Monday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLevels(t, "Retarget", retargeted, map[time.Time]Level{
+		date(2024, time.January, 7): 4,
+		date(2024, time.January, 8): 2,
+	})
+
+	if _, err := Retarget(c, 2024, time.Tuesday); err == nil {
+		t.Error("Retarget with tuesday week start succeeded")
+	}
+}
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		year      int
+		weekStart time.Weekday
+		weeks     int
+		offset    int
+	}{
+		{year: 2023, weekStart: time.Sunday, weeks: 53, offset: 0},
+		{year: 2023, weekStart: time.Monday, weeks: 53, offset: 6},
+		{year: 2024, weekStart: time.Sunday, weeks: 53, offset: 1},
+		{year: 2024, weekStart: time.Monday, weeks: 53, offset: 0},
+		{year: 2022, weekStart: time.Monday, weeks: 53, offset: 5},
+	}
+
+	for _, v := range tests {
+		l, err := NewLayout(v.year, v.weekStart)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Weeks() != v.weeks {
+			t.Errorf("%v %v: Weeks() = %v, want: %v", v.year, v.weekStart, l.Weeks(), v.weeks)
+		}
+		if week, row := l.Position(0); week != 0 || row != v.offset {
+			t.Errorf("%v %v: Position(0) = (%v, %v), want: (0, %v)", v.year, v.weekStart, week, row, v.offset)
+		}
+		for i := 0; i < daysInYear(v.year); i++ {
+			week, row := l.Position(i)
+			day := time.Date(v.year, time.January, 1+i, 0, 0, 0, 0, time.UTC)
+			if l.Row(day.Weekday()) != row {
+				t.Errorf("%v %v: Row(%v) = %v, want: %v", v.year, v.weekStart, day.Weekday(), l.Row(day.Weekday()), row)
+			}
+			if j, ok := l.Index(week, row); !ok || j != i {
+				t.Errorf("%v %v: Index(%v, %v) = (%v, %v), want: (%v, true)", v.year, v.weekStart, week, row, j, ok, i)
+			}
+		}
+		if _, ok := l.Index(0, v.offset-1); ok && v.offset != 0 {
+			t.Errorf("%v %v: day before the year is part of the layout", v.year, v.weekStart)
+		}
+	}
+
+	if _, err := NewLayout(2023, time.Saturday); err == nil {
+		t.Error("NewLayout with saturday week start succeeded")
+	}
+}
+
+func TestRetargetRange(t *testing.T) {
+	c := newTestChart(t, 2023, map[time.Time]Level{
+		date(2023, time.January, 1): 4,
+		date(2023, time.January, 9): 2,
+	})
+
+	// 2023-10-15 is a sunday, so the days keep their weekdays.
+	res, err := RetargetRange(c, date(2023, time.October, 15), 53, time.Sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLevels(t, "RetargetRange", res, map[time.Time]Level{
+		date(2023, time.October, 15): 4,
+		date(2023, time.October, 23): 2,
+	})
+
+	if _, err := Union(c, res); err == nil {
+		t.Error("Union of a year and a range chart succeeded")
+	}
+
+	// Range charts that do not start on the week start, have a partial first week.
+	res, err = RetargetRange(c, date(2023, time.October, 16), 53, time.Sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLevels(t, "RetargetRange", res, map[time.Time]Level{
+		date(2023, time.October, 23): 2,
+	})
+}
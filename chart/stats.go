@@ -0,0 +1,119 @@
+package chart
+
+import "time"
+
+// StatsOptions are the options of Chart.Stats.
+type StatsOptions struct {
+	// Today is the calendar date that future days are relative to,
+	// the zero value treats all days as past days.
+	Today time.Time
+
+	// WeekStart is the first weekday of the chart layout (see NewLayout),
+	// it is used to count the weeks touched.
+	WeekStart time.Weekday
+}
+
+// Period is a range of consecutive days, Days is zero for an empty period.
+type Period struct {
+	Start, End time.Time
+	Days       int
+}
+
+// MonthDays is the number of set days in a month.
+type MonthDays struct {
+	Year  int
+	Month time.Month
+	Days  int
+}
+
+// Stats are statistics of the set days of a chart.
+type Stats struct {
+	// Days is the number of set days.
+	Days int
+
+	// Months are the numbers of set days of every month that the chart covers,
+	// in chronological order (including months without any set days).
+	Months []MonthDays
+
+	// Weekdays are the numbers of set days of every weekday, indexed by time.Weekday.
+	Weekdays [7]int
+
+	// LongestStreak is the longest period of set days.
+	LongestStreak Period
+
+	// LongestGap is the longest period of not set days, between two set days.
+	LongestGap Period
+
+	// WeeksTouched is the number of weeks (columns of the layout) with at least one set day.
+	WeeksTouched int
+
+	// FutureDays are the set days after StatsOptions.Today, as midnight UTC times.
+	FutureDays []time.Time
+}
+
+// Stats computes statistics of the chart, when there are many streaks
+// (or gaps) of the longest length, the earliest one is returned.
+func (c *Chart) Stats(o *StatsOptions) (Stats, error) {
+	l, err := c.Layout(o.WeekStart)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	var (
+		s        Stats
+		streak   Period
+		lastSet  = -1
+		lastWeek = -1
+	)
+
+	// Days starting at the future index are after today.
+	future := c.Len()
+	if !o.Today.IsZero() {
+		if today := calendarDate(o.Today); today.Before(c.start) {
+			future = 0
+		} else if i, ok := c.dayIndex(today); ok {
+			future = i + 1
+		}
+	}
+
+	for i, v := range c.levels {
+		day := c.Day(i)
+		if i == 0 || day.Day() == 1 {
+			s.Months = append(s.Months, MonthDays{Year: day.Year(), Month: day.Month()})
+		}
+
+		if v == 0 {
+			streak = Period{}
+			continue
+		}
+
+		s.Days++
+		s.Months[len(s.Months)-1].Days++
+		s.Weekdays[day.Weekday()]++
+
+		if streak.Days == 0 {
+			streak.Start = day
+		}
+		streak.End = day
+		streak.Days++
+		if streak.Days > s.LongestStreak.Days {
+			s.LongestStreak = streak
+		}
+
+		if gap := i - lastSet - 1; lastSet != -1 && gap > s.LongestGap.Days {
+			s.LongestGap = Period{Start: c.Day(lastSet + 1), End: c.Day(i - 1), Days: gap}
+		}
+		lastSet = i
+
+		if week, _ := l.Position(i); week != lastWeek {
+			s.WeeksTouched++
+			lastWeek = week
+		}
+
+		if i >= future {
+			s.FutureDays = append(s.FutureDays, day)
+		}
+	}
+
+	return s, nil
+}
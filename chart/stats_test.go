@@ -0,0 +1,63 @@
+package chart
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	c := newTestChart(t, 2023, map[time.Time]Level{
+		date(2023, time.January, 1):   4, // sunday
+		date(2023, time.January, 2):   1,
+		date(2023, time.January, 3):   2,
+		date(2023, time.January, 10):  4,
+		date(2023, time.February, 1):  4,
+		date(2023, time.February, 2):  4,
+		date(2023, time.December, 31): 3,
+	})
+
+	s, err := c.Stats(&StatsOptions{Today: time.Date(2023, time.February, 1, 23, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Days != 7 {
+		t.Errorf("Days = %v, want: 7", s.Days)
+	}
+	if len(s.Months) != 12 || s.Months[0].Days != 4 || s.Months[1].Days != 2 || s.Months[11].Days != 1 || s.Months[11].Month != time.December {
+		t.Errorf("Months = %v", s.Months)
+	}
+	if want := [7]int{2, 1, 2, 1, 1, 0, 0}; s.Weekdays != want {
+		t.Errorf("Weekdays = %v, want: %v", s.Weekdays, want)
+	}
+	if want := (Period{Start: date(2023, time.January, 1), End: date(2023, time.January, 3), Days: 3}); s.LongestStreak != want {
+		t.Errorf("LongestStreak = %v, want: %v", s.LongestStreak, want)
+	}
+	if want := (Period{Start: date(2023, time.February, 3), End: date(2023, time.December, 30), Days: 331}); s.LongestGap != want {
+		t.Errorf("LongestGap = %v, want: %v", s.LongestGap, want)
+	}
+	// Weeks 0, 1, 4 and 52.
+	if s.WeeksTouched != 4 {
+		t.Errorf("WeeksTouched = %v, want: 4", s.WeeksTouched)
+	}
+	if len(s.FutureDays) != 2 || !s.FutureDays[0].Equal(date(2023, time.February, 2)) {
+		t.Errorf("FutureDays = %v", s.FutureDays)
+	}
+
+	// With weeks starting on monday, 2023-01-01 is the only day of week 0.
+	s, err = c.Stats(&StatsOptions{WeekStart: time.Monday})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.WeeksTouched != 5 || len(s.FutureDays) != 0 {
+		t.Errorf("monday week start: WeeksTouched = %v, FutureDays = %v, want: 5, []", s.WeeksTouched, s.FutureDays)
+	}
+
+	s, err = c.Stats(&StatsOptions{Today: date(2022, time.December, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.FutureDays) != s.Days {
+		t.Errorf("today before the chart: %v future days, want: %v", len(s.FutureDays), s.Days)
+	}
+}
@@ -0,0 +1,137 @@
+package chart
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Align is the horizontal alignment of a text.
+type Align uint8
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// TextOptions are the options of Text.
+type TextOptions struct {
+	Align Align
+
+	// StartWeek is the first week (zero-based column of the chart), that the text
+	// is aligned in, weeks before it are left empty.
+	StartWeek int
+
+	// Spacing is the number of empty weeks between two characters.
+	Spacing int
+
+	// Level is the intensity level of the text, zero means MaxLevel.
+	Level Level
+
+	// WeekStart is the first weekday of the chart layout (see NewLayout),
+	// the zero value is sunday (github).
+	WeekStart time.Weekday
+}
+
+// DefaultTextOptions returns left aligned options with a single
+// empty week between characters.
+func DefaultTextOptions() TextOptions {
+	return TextOptions{Spacing: 1}
+}
+
+// TextLayout describes where the text was placed.
+type TextLayout struct {
+	// FirstWeek is the week of the first column of the text, it is less
+	// than StartWeek (or negative) when the text does not fit after StartWeek.
+	FirstWeek int
+
+	// Width is the width of the text in weeks.
+	Width int
+
+	// Clipped is the number of set pixels of the text, that did not fit
+	// in the chart (because they fall on days outside of the year, or
+	// in weeks before StartWeek).
+	Clipped int
+}
+
+// UnsupportedRuneError is returned by Text for runes without a glyph in the font.
+type UnsupportedRuneError struct {
+	Rune rune
+}
+
+func (e *UnsupportedRuneError) Error() string {
+	return fmt.Sprintf("unsupported character: %q", e.Rune)
+}
+
+var errInvalidTextOptions = errors.New("invalid text options")
+
+// Text creates a chart of the year with the text drawn (using a
+// built-in 7 pixels high font) in the weeks of the chart.
+func Text(year int, text string, o *TextOptions) (*Chart, TextLayout, error) {
+	c, err := New(year)
+	if err != nil {
+		return nil, TextLayout{}, err
+	}
+
+	if o.Align > AlignRight || o.Spacing < 0 || o.Level > MaxLevel {
+		return nil, TextLayout{}, errInvalidTextOptions
+	}
+
+	l, err := c.Layout(o.WeekStart)
+	if err != nil {
+		return nil, TextLayout{}, err
+	}
+
+	weeks := l.Weeks()
+	if o.StartWeek < 0 || o.StartWeek >= weeks {
+		return nil, TextLayout{}, errInvalidTextOptions
+	}
+
+	level := o.Level
+	if level == 0 {
+		level = MaxLevel
+	}
+
+	var glyphs []glyph
+	width := 0
+	for _, r := range text {
+		g, ok := fontGlyph(r)
+		if !ok {
+			return nil, TextLayout{}, &UnsupportedRuneError{Rune: r}
+		}
+		if len(glyphs) != 0 {
+			width += o.Spacing
+		}
+		glyphs = append(glyphs, g)
+		width += g.width()
+	}
+
+	layout := TextLayout{FirstWeek: o.StartWeek, Width: width}
+	switch o.Align {
+	case AlignCenter:
+		layout.FirstWeek += (weeks - o.StartWeek - width) / 2
+	case AlignRight:
+		layout.FirstWeek += weeks - o.StartWeek - width
+	}
+
+	week := layout.FirstWeek
+	for _, g := range glyphs {
+		for x := 0; x < g.width(); x++ {
+			for y := 0; y < 7; y++ {
+				if !g.isSet(x, y) {
+					continue
+				}
+				i, ok := l.Index(week+x, y)
+				if !ok || week+x < o.StartWeek {
+					layout.Clipped++
+					continue
+				}
+				c.levels[i] = level
+			}
+		}
+		week += g.width() + o.Spacing
+	}
+
+	return c, layout, nil
+}
@@ -0,0 +1,86 @@
+package chart
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFont(t *testing.T) {
+	for r, g := range font {
+		for i, row := range g {
+			if len(row) != g.width() {
+				t.Errorf("glyph %q: row %v has width %v, want: %v", r, i, len(row), g.width())
+			}
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	o := DefaultTextOptions()
+	o.StartWeek = 1
+
+	// 2023 starts on a sunday, so the week 1 starts on 2023-01-08.
+	c, layout, err := Text(2023, "Hi", &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (layout != TextLayout{FirstWeek: 1, Width: 5 + 1 + 3}) {
+		t.Errorf("unexpected layout: %#v", layout)
+	}
+
+	// First column of 'H' is fully set.
+	for i := 0; i < 7; i++ {
+		day := time.Date(2023, time.January, 8+i, 0, 0, 0, 0, time.UTC)
+		if !c.IsSet(day) {
+			t.Errorf("%v is not set", day)
+		}
+	}
+
+	// Top row of 'I' is fully set.
+	for i := 0; i < 3; i++ {
+		day := time.Date(2023, time.January, 8+6*7+i*7, 0, 0, 0, 0, time.UTC)
+		if !c.IsSet(day) {
+			t.Errorf("%v is not set", day)
+		}
+	}
+
+	if want := (7*2 + 3) + (3*2 + 5); c.Count() != want {
+		t.Errorf("Count() = %v, want: %v", c.Count(), want)
+	}
+
+	o = DefaultTextOptions()
+	o.Align = AlignRight
+	_, layout, err = Text(2023, "IIIIIIIIIIIIIIIIIIIII", &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout.Clipped == 0 || layout.FirstWeek >= 0 {
+		t.Errorf("expected clipped text, got layout: %#v", layout)
+	}
+
+	o = DefaultTextOptions()
+	o.Align = AlignRight
+	o.StartWeek = 40
+	for _, text := range []string{"Hi", "IIIIIIIIIIIIIIIIIIIII"} {
+		c, layout, err := Text(2023, text, &o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if end := layout.FirstWeek + layout.Width; end != 53 {
+			t.Errorf("%q: right aligned text ends at week %v, want: 53", text, end)
+		}
+		for _, day := range c.Days() {
+			// 2023-10-08 is the first day of the week 40.
+			if day.Before(time.Date(2023, time.October, 8, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("%q: %v before StartWeek is set", text, day)
+			}
+		}
+	}
+
+	var unsupportedErr *UnsupportedRuneError
+	if _, _, err := Text(2023, "a€", &o); !errors.As(err, &unsupportedErr) || unsupportedErr.Rune != '€' {
+		t.Errorf("Text with unsupported rune returned: %v", err)
+	}
+}
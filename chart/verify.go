@@ -0,0 +1,112 @@
+package chart
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ParseCommitDates parses commit dates in the strict ISO 8601 format,
+// one per line, as printed by: git log --format=%aI. Empty lines are ignored.
+func ParseCommitDates(r io.Reader) ([]time.Time, error) {
+	var dates []time.Time
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: invalid commit date: %q", line, text)
+		}
+		dates = append(dates, date)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return dates, nil
+}
+
+// TimezoneMismatch is a commit, that is shown on a different day than intended.
+type TimezoneMismatch struct {
+	// Commit is the commit date (with its UTC offset).
+	Commit time.Time
+
+	// Day is the day on which the commit is shown,
+	// Intended is the day in the intended timezone.
+	Day, Intended time.Time
+}
+
+// Verification is the difference between the commits of a repository and a chart.
+// All days are calendar dates as midnight UTC times, in chronological order.
+type Verification struct {
+	// Missing are the set days of the chart without any commits.
+	Missing []time.Time
+
+	// Unexpected are the not set days of the chart with commits.
+	Unexpected []time.Time
+
+	// WrongTimezone are the commits on days of the chart, that are shown on
+	// a different day than their day in the intended timezone, in the order
+	// of the commit dates.
+	WrongTimezone []TimezoneMismatch
+
+	// Outside is the number of commits on days not covered by the chart.
+	Outside int
+}
+
+// OK reports whether the commits reproduce the chart.
+func (v *Verification) OK() bool {
+	return len(v.Missing) == 0 && len(v.Unexpected) == 0 && len(v.WrongTimezone) == 0
+}
+
+// Verify compares the commit dates with the chart. Same as on github, a commit
+// is shown on its calendar date in the UTC offset of the commit date, loc is the
+// intended timezone of commits (nil means UTC), e.g. the one used to reproduce the chart.
+func Verify(c *Chart, commits []time.Time, loc *time.Location) Verification {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var (
+		v   Verification
+		has = make([]bool, c.Len())
+	)
+
+	for _, commit := range commits {
+		day := calendarDate(commit)
+		i, ok := c.dayIndex(day)
+		if !ok {
+			v.Outside++
+			continue
+		}
+		has[i] = true
+
+		if intended := calendarDate(commit.In(loc)); !intended.Equal(day) {
+			v.WrongTimezone = append(v.WrongTimezone, TimezoneMismatch{
+				Commit:   commit,
+				Day:      day,
+				Intended: intended,
+			})
+		}
+	}
+
+	for i, level := range c.levels {
+		switch {
+		case level != 0 && !has[i]:
+			v.Missing = append(v.Missing, c.Day(i))
+		case level == 0 && has[i]:
+			v.Unexpected = append(v.Unexpected, c.Day(i))
+		}
+	}
+
+	// git log prints the newest commits first.
+	sort.SliceStable(v.WrongTimezone, func(i, j int) bool {
+		return v.WrongTimezone[i].Commit.Before(v.WrongTimezone[j].Commit)
+	})
+	return v
+}
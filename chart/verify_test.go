@@ -0,0 +1,62 @@
+package chart
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	c := newTestChart(t, 2023, map[time.Time]Level{
+		date(2023, time.January, 1): 4,
+		date(2023, time.January, 2): 2,
+		date(2023, time.January, 3): 4,
+		date(2023, time.January, 5): 4,
+	})
+
+	log := `
+2024-01-01T12:00:00+02:00
+2023-01-05T23:30:00-05:00
+2023-01-04T12:00:00+02:00
+2023-01-02T12:01:00+02:00
+2023-01-02T12:00:00+02:00
+2023-01-01T00:30:00+02:00
+`
+	commits, err := ParseCommitDates(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := Verify(c, commits, time.FixedZone("", 2*3600))
+	if v.OK() {
+		t.Error("OK() = true, want: false")
+	}
+	if want := []time.Time{date(2023, time.January, 3)}; !reflect.DeepEqual(v.Missing, want) {
+		t.Errorf("Missing = %v, want: %v", v.Missing, want)
+	}
+	if want := []time.Time{date(2023, time.January, 4)}; !reflect.DeepEqual(v.Unexpected, want) {
+		t.Errorf("Unexpected = %v, want: %v", v.Unexpected, want)
+	}
+	if len(v.WrongTimezone) != 1 || !v.WrongTimezone[0].Commit.Equal(commits[1]) ||
+		v.WrongTimezone[0].Day != date(2023, time.January, 5) || v.WrongTimezone[0].Intended != date(2023, time.January, 6) {
+		t.Errorf("WrongTimezone = %v", v.WrongTimezone)
+	}
+	if v.Outside != 1 {
+		t.Errorf("Outside = %v, want: 1", v.Outside)
+	}
+
+	v = Verify(c, []time.Time{
+		time.Date(2023, time.January, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2023, time.January, 2, 12, 0, 0, 0, time.UTC),
+		time.Date(2023, time.January, 3, 12, 0, 0, 0, time.UTC),
+		time.Date(2023, time.January, 5, 12, 0, 0, 0, time.UTC),
+	}, nil)
+	if !v.OK() {
+		t.Errorf("unexpected verification of a matching history: %+v", v)
+	}
+
+	if _, err := ParseCommitDates(strings.NewReader("2023-01-01\n")); err == nil {
+		t.Error("ParseCommitDates: unexpected success of a date without time")
+	}
+}
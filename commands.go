@@ -0,0 +1,612 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+	"github.com/mateusz834/charts/render"
+	"github.com/mateusz834/charts/reproduce"
+	"github.com/mateusz834/charts/storage"
+)
+
+const dateLayout = "2006-01-02"
+
+// encode reads ISO dates (one per line, optionally followed by a level)
+// from stdin and prints the encoded chart. Empty lines and lines
+// starting with '#' are ignored.
+func encode(args []string) error {
+	flags := flag.NewFlagSet("encode", flag.ExitOnError)
+	year := flags.Int("year", 0, "year of the chart, defaults to the year of the first date")
+	start := flags.String("start", "", "first day (YYYY-MM-DD) of a range chart")
+	weeks := flags.Int("weeks", 0, "number of weeks of a range chart")
+	version := flags.String("version", "", "encoding version, defaults to the smallest encoding")
+	flags.Parse(args)
+
+	type day struct {
+		date  time.Time
+		level chart.Level
+	}
+
+	var days []day
+	s := bufio.NewScanner(os.Stdin)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) > 2 {
+			return fmt.Errorf("line %v: expected a date optionally followed by a level", line)
+		}
+
+		date, err := time.Parse(dateLayout, fields[0])
+		if err != nil {
+			return fmt.Errorf("line %v: %v", line, err)
+		}
+
+		level := chart.MaxLevel
+		if len(fields) == 2 {
+			n, err := strconv.ParseUint(fields[1], 10, 8)
+			if err != nil || chart.Level(n) > chart.MaxLevel {
+				return fmt.Errorf("line %v: invalid level: %q", line, fields[1])
+			}
+			level = chart.Level(n)
+		}
+
+		days = append(days, day{date: date, level: level})
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	var (
+		c   *chart.Chart
+		err error
+	)
+	switch {
+	case *start != "" || *weeks != 0:
+		if *year != 0 {
+			return errors.New("-year cannot be used together with -start and -weeks")
+		}
+		startDate, err := time.Parse(dateLayout, *start)
+		if err != nil {
+			return fmt.Errorf("invalid -start: %v", err)
+		}
+		c, err = chart.NewRange(startDate, *weeks)
+		if err != nil {
+			return err
+		}
+	default:
+		if *year == 0 {
+			if len(days) == 0 {
+				return errors.New("no dates and no -year specified")
+			}
+			*year = days[0].date.Year()
+		}
+		c, err = chart.New(*year)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, v := range days {
+		if err := c.SetLevel(v.date, v.level); err != nil {
+			return fmt.Errorf("%v: %v", v.date.Format(dateLayout), err)
+		}
+	}
+
+	var enc string
+	if *version != "" {
+		if len(*version) != 1 {
+			return fmt.Errorf("invalid -version: %q", *version)
+		}
+		enc, err = chart.EncodeVersion(c, chart.Version((*version)[0]))
+	} else {
+		enc, err = chart.Encode(c)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Println(enc)
+	return err
+}
+
+// chartArg decodes the chart from the only positional argument,
+// or from stdin when there is none.
+func chartArg(flags *flag.FlagSet) (*chart.Chart, error) {
+	switch flags.NArg() {
+	case 0:
+		enc, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		return chart.Decode(strings.TrimSpace(string(enc)))
+	case 1:
+		return chart.Decode(flags.Arg(0))
+	default:
+		return nil, errors.New("expected a single encoded chart")
+	}
+}
+
+// outputFile returns stdout for an empty path or "-", otherwise it creates the file.
+func outputFile(path string) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// writeOutput calls f with the output file (see outputFile).
+func writeOutput(path string, f func(w io.Writer) error) error {
+	out, err := outputFile(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	if err := f(w); err != nil {
+		out.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+type decodedDay struct {
+	Date  string      `json:"date"`
+	Level chart.Level `json:"level"`
+}
+
+// decode prints the set days of an encoded chart, either as
+// lines of "YYYY-MM-DD level" (accepted by encode) or as JSON.
+func decode(args []string) error {
+	flags := flag.NewFlagSet("decode", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "print the chart as JSON")
+	flags.Parse(args)
+
+	c, err := chartArg(flags)
+	if err != nil {
+		return err
+	}
+
+	days := []decodedDay{}
+	for _, v := range c.Days() {
+		days = append(days, decodedDay{Date: v.Format(dateLayout), Level: c.Level(v)})
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if *jsonOutput {
+		err = json.NewEncoder(w).Encode(struct {
+			Range bool         `json:"range"`
+			Start string       `json:"start"`
+			End   string       `json:"end"`
+			Weeks int          `json:"weeks,omitempty"`
+			Days  []decodedDay `json:"days"`
+		}{
+			Range: c.IsRange(),
+			Start: c.Start().Format(dateLayout),
+			End:   c.End().Format(dateLayout),
+			Weeks: c.Weeks(),
+			Days:  days,
+		})
+	} else {
+		for _, v := range days {
+			fmt.Fprintf(w, "%v %v\n", v.Date, v.Level)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func parseTheme(name string) (render.Theme, error) {
+	switch name {
+	case "light":
+		return render.LightTheme, nil
+	case "dark":
+		return render.DarkTheme, nil
+	default:
+		return render.Theme{}, fmt.Errorf("unknown theme: %q", name)
+	}
+}
+
+// renderCmd renders an encoded chart to a file.
+func renderCmd(args []string) error {
+	defaults := render.DefaultOptions()
+
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	format := flags.String("format", "svg", "output format: svg, png or text")
+	output := flags.String("o", "", "output file, defaults to stdout")
+	cell := flags.Int("cell", defaults.CellSize, "cell size in pixels")
+	gap := flags.Int("gap", defaults.CellGap, "gap between cells in pixels")
+	theme := flags.String("theme", "light", "theme: light or dark")
+	labels := flags.Bool("labels", true, "draw month and weekday labels")
+	weekStart := flags.String("week-start", "sunday", "first weekday of every week: sunday or monday")
+	ascii := flags.Bool("ascii", false, "text: use only ASCII characters")
+	colors := flags.Bool("color", false, "text: use ANSI colors")
+	flags.Parse(args)
+
+	c, err := chartArg(flags)
+	if err != nil {
+		return err
+	}
+
+	ws, err := chart.ParseWeekStart(*weekStart)
+	if err != nil {
+		return err
+	}
+	t, err := parseTheme(*theme)
+	if err != nil {
+		return err
+	}
+
+	o := render.Options{
+		CellSize:  *cell,
+		CellGap:   *gap,
+		Theme:     t,
+		Labels:    *labels,
+		WeekStart: ws,
+	}
+
+	var f func(w io.Writer) error
+	switch *format {
+	case "svg":
+		f = func(w io.Writer) error { return render.SVG(w, c, &o) }
+	case "png":
+		f = func(w io.Writer) error { return render.PNG(w, c, &o) }
+	case "text":
+		to := render.TerminalOptions{
+			ASCII:     *ascii,
+			Colors:    *colors,
+			Labels:    *labels,
+			WeekStart: ws,
+			Theme:     t,
+		}
+		f = func(w io.Writer) error { return render.Terminal(w, c, &to) }
+	default:
+		return fmt.Errorf("unknown format: %q", *format)
+	}
+
+	return writeOutput(*output, f)
+}
+
+// script writes a script that reproduces an encoded chart using git.
+func script(args []string) error {
+	defaults := reproduce.DefaultOptions()
+
+	flags := flag.NewFlagSet("script", flag.ExitOnError)
+	shell := flags.String("shell", reproduce.Bash.String(), "shell of the script (see reproduce.ParseShell)")
+	output := flags.String("o", "", "output file, defaults to stdout")
+	message := flags.String("message", defaults.Message, "commit message, {date}, {n} and {level} are replaced (see reproduce.Options.Message)")
+	name := flags.String("name", "", "commit author and committer name, defaults to the git config")
+	email := flags.String("email", "", "commit author and committer email, defaults to the git config")
+	tz := flags.String("tz", "UTC", "IANA timezone name or UTC offset of the commit dates")
+	commits := flags.Int("commits", defaults.CommitsPerDay, "commits per day with the maximum level")
+	flags.Parse(args)
+
+	c, err := chartArg(flags)
+	if err != nil {
+		return err
+	}
+
+	loc, err := chart.ParseLocation(*tz)
+	if err != nil {
+		return err
+	}
+
+	o := reproduce.ScriptOptions{
+		Options: reproduce.Options{
+			Message:       *message,
+			AuthorName:    *name,
+			AuthorEmail:   *email,
+			Location:      loc,
+			CommitsPerDay: *commits,
+		},
+	}
+	o.Shell, err = reproduce.ParseShell(*shell)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*output, func(w io.Writer) error {
+		return reproduce.Script(w, c, &o)
+	})
+}
+
+// fastImport writes a git fast-import stream that reproduces an encoded chart.
+func fastImport(args []string) error {
+	defaults := reproduce.DefaultFastImportOptions()
+
+	flags := flag.NewFlagSet("fast-import", flag.ExitOnError)
+	output := flags.String("o", "", "output file, defaults to stdout")
+	message := flags.String("message", defaults.Message, "commit message, {date}, {n} and {level} are replaced (see reproduce.Options.Message)")
+	name := flags.String("name", "", "commit author and committer name")
+	email := flags.String("email", "", "commit author and committer email (required)")
+	tz := flags.String("tz", "UTC", "IANA timezone name or UTC offset of the commit dates")
+	commits := flags.Int("commits", defaults.CommitsPerDay, "commits per day with the maximum level")
+	branch := flags.String("branch", defaults.Branch, "name of the created branch")
+	file := flags.String("file", defaults.File, "path of the file changed by every commit")
+	flags.Parse(args)
+
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+
+	c, err := chartArg(flags)
+	if err != nil {
+		return err
+	}
+
+	loc, err := chart.ParseLocation(*tz)
+	if err != nil {
+		return err
+	}
+
+	o := reproduce.FastImportOptions{
+		Options: reproduce.Options{
+			Message:       *message,
+			AuthorName:    *name,
+			AuthorEmail:   *email,
+			Location:      loc,
+			CommitsPerDay: *commits,
+		},
+		Branch: *branch,
+		File:   *file,
+	}
+
+	return writeOutput(*output, func(w io.Writer) error {
+		return reproduce.FastImport(w, c, &o)
+	})
+}
+
+// fetchShare fetches the chart and the timezone of a share from a server, ref is
+// either a share URL (https://host/s/path) or a share URL path (/s/path)
+// resolved against the server URL.
+func fetchShare(ref, server string) (*chart.Chart, string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, "", err
+	}
+	if u.Host == "" {
+		if server == "" {
+			return nil, "", errors.New("-server is required for share URL paths")
+		}
+		base, err := url.Parse(server)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid -server: %v", err)
+		}
+		u = base.ResolveReference(u)
+	}
+
+	path, ok := strings.CutPrefix(u.Path, "/s/")
+	if !ok || path == "" {
+		return nil, "", fmt.Errorf("not a share URL: %q", ref)
+	}
+	u.Path = "/share/" + path
+	u.RawQuery = ""
+
+	res, err := http.Get(u.String())
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch share %q: %v", path, res.Status)
+	}
+
+	var share struct {
+		Chart    string `json:"chart"`
+		Timezone string `json:"timezone"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&share); err != nil {
+		return nil, "", err
+	}
+
+	c, err := chart.Decode(share.Chart)
+	if err != nil {
+		return nil, "", err
+	}
+	return c, share.Timezone, nil
+}
+
+// chartOrShare decodes the chart like chartArg, or fetches it (see fetchShare) when the
+// argument is a share URL. It also returns the timezone tz, defaulting to the timezone
+// of the share or UTC.
+func chartOrShare(flags *flag.FlagSet, server, tz string) (*chart.Chart, *time.Location, error) {
+	var (
+		c       *chart.Chart
+		shareTZ string
+		err     error
+	)
+	if flags.NArg() == 1 && strings.Contains(flags.Arg(0), "/") {
+		c, shareTZ, err = fetchShare(flags.Arg(0), server)
+	} else {
+		c, err = chartArg(flags)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, v := range []string{tz, shareTZ} {
+		if v != "" {
+			loc, err := chart.ParseLocation(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			return c, loc, nil
+		}
+	}
+	return c, time.UTC, nil
+}
+
+// apply creates commits reproducing an encoded chart or a share in a local git repository.
+func apply(args []string) error {
+	defaults := reproduce.DefaultOptions()
+
+	flags := flag.NewFlagSet("apply", flag.ExitOnError)
+	dir := flags.String("dir", ".", "path of the git repository")
+	dryRun := flags.Bool("dry-run", false, "only print the days, without creating any commits")
+	server := flags.String("server", "", "server URL, used to fetch shares specified by a share URL path (/s/path)")
+	message := flags.String("message", defaults.Message, "commit message, {date}, {n} and {level} are replaced (see reproduce.Options.Message)")
+	name := flags.String("name", "", "commit author and committer name, defaults to the git config")
+	email := flags.String("email", "", "commit author and committer email, defaults to the git config")
+	tz := flags.String("tz", "", "IANA timezone name or UTC offset of the commit dates, defaults to the timezone of the share or UTC")
+	commits := flags.Int("commits", defaults.CommitsPerDay, "commits per day with the maximum level")
+	flags.Parse(args)
+
+	c, loc, err := chartOrShare(flags, *server, *tz)
+	if err != nil {
+		return err
+	}
+
+	o := reproduce.ApplyOptions{
+		Options: reproduce.Options{
+			Message:       *message,
+			AuthorName:    *name,
+			AuthorEmail:   *email,
+			Location:      loc,
+			CommitsPerDay: *commits,
+		},
+		Dir:    *dir,
+		DryRun: *dryRun,
+	}
+
+	days, err := reproduce.Apply(c, &o)
+
+	var created, skipped int
+	for _, v := range days {
+		if v.Skipped {
+			skipped++
+			fmt.Printf("%v skipped, already has commits\n", v.Date.Format(dateLayout))
+			continue
+		}
+		created += v.Commits
+		fmt.Printf("%v %v commits\n", v.Date.Format(dateLayout), v.Commits)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Printf("dry run: %v commits would be created, %v days skipped\n", created, skipped)
+	} else {
+		fmt.Printf("%v commits created, %v days skipped\n", created, skipped)
+	}
+	return nil
+}
+
+// verify compares the commit dates printed by: git log --format=%aI
+// with an encoded chart or a share, it fails when they differ.
+func verify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	logPath := flags.String("log", "-", "file with the git log output, defaults to stdin")
+	server := flags.String("server", "", "server URL, used to fetch shares specified by a share URL path (/s/path)")
+	tz := flags.String("tz", "", "IANA timezone name or UTC offset of the intended commit dates, defaults to the timezone of the share or UTC")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return errors.New("expected a single encoded chart or share URL")
+	}
+
+	c, loc, err := chartOrShare(flags, *server, *tz)
+	if err != nil {
+		return err
+	}
+
+	in := io.Reader(os.Stdin)
+	if *logPath != "-" {
+		f, err := os.Open(*logPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	commits, err := chart.ParseCommitDates(in)
+	if err != nil {
+		return err
+	}
+
+	v := chart.Verify(c, commits, loc)
+	for _, v := range v.Missing {
+		fmt.Printf("%v missing commits\n", v.Format(dateLayout))
+	}
+	for _, v := range v.Unexpected {
+		fmt.Printf("%v unexpected commits\n", v.Format(dateLayout))
+	}
+	for _, v := range v.WrongTimezone {
+		fmt.Printf("%v commit %v is shown instead of on %v\n",
+			v.Day.Format(dateLayout), v.Commit.Format(time.RFC3339), v.Intended.Format(dateLayout))
+	}
+	if v.Outside != 0 {
+		fmt.Printf("%v commits outside of the chart\n", v.Outside)
+	}
+
+	if !v.OK() {
+		return fmt.Errorf("history does not match the chart: %v missing, %v unexpected, %v wrong timezone",
+			len(v.Missing), len(v.Unexpected), len(v.WrongTimezone))
+	}
+	fmt.Println("history matches the chart")
+	return nil
+}
+
+// migrate applies the pending schema migrations to the database from the config,
+// the server applies them at startup too.
+func migrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	confPath := flags.String("config", "./config.json", "path of the config file")
+	status := flags.Bool("status", false, "only print the schema version and the pending migrations")
+	dryRun := flags.Bool("dry-run", false, "same as -status")
+	flags.Parse(args)
+
+	c, err := LoadConfig(*confPath)
+	if err != nil {
+		return err
+	}
+
+	if *status || *dryRun {
+		version, pending, err := storage.SqliteSchemaStatus(c.DB)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("schema version %v, latest version %v\n", version, storage.LatestSchemaVersion())
+		for _, m := range pending {
+			fmt.Printf("pending migration %v: %v\n", m.Version, m.Description)
+		}
+		return nil
+	}
+
+	applied, err := storage.MigrateSqlite(c.DB)
+	for _, m := range applied {
+		fmt.Printf("applied migration %v: %v\n", m.Version, m.Description)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Printf("schema is up to date (version %v)\n", storage.LatestSchemaVersion())
+	}
+	return nil
+}
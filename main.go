@@ -4,7 +4,11 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/mateusz834/charts/app"
 	"github.com/mateusz834/charts/log"
@@ -13,27 +17,91 @@ import (
 )
 
 func main() {
-	if err := run(); err != nil {
+	if err := run(os.Args[1:]); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
-func run() error {
-	confPath := flag.String("config", "./config.json", "")
-	flag.Parse()
+// commands are the subcommands of the binary, see usage.
+var commands = map[string]func(args []string) error{
+	"serve":       serve,
+	"encode":      encode,
+	"decode":      decode,
+	"render":      renderCmd,
+	"script":      script,
+	"fast-import": fastImport,
+	"apply":       apply,
+	"verify":      verify,
+	"migrate":     migrate,
+}
+
+const usage = `usage: charts <command> [flags]
+
+commands:
+  serve        start the server (default, when no command is specified)
+  encode       encode a chart from ISO dates (YYYY-MM-DD [level]) read from stdin
+  decode       decode a chart to dates or JSON
+  render       render a chart as an svg, png or text
+  script       generate a git reproducer script of a chart
+  fast-import  generate a git fast-import stream of a chart
+  apply        create commits reproducing a chart (or a share) in a local git repository
+  verify       compare git log --format=%aI output (from stdin) with a chart (or a share)
+  migrate      apply the pending database schema migrations (-status lists them)
+
+Run "charts <command> -h" for the flags of a command.
+`
+
+func run(args []string) error {
+	// Flags without a command, start the server (as before subcommands existed).
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return serve(args)
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command: %q", args[0])
+	}
+	return cmd(args[1:])
+}
+
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	confPath := flags.String("config", "./config.json", "path of the config file")
+	flags.Parse(args)
 
 	c, err := LoadConfig(*confPath)
 	if err != nil {
 		return err
 	}
 
-	db, err := storage.NewSqliteStorage(c.DB)
+	var publicURL *url.URL
+	if c.PublicURL != "" {
+		publicURL, err = url.Parse(c.PublicURL)
+		if err != nil || (publicURL.Scheme != "http" && publicURL.Scheme != "https") || publicURL.Host == "" {
+			return fmt.Errorf("PublicURL must be an absolute http or https URL, got: %q", c.PublicURL)
+		}
+	}
+
+	switch http.CanonicalHeaderKey(c.TrustedProxyHeader) {
+	case "", "X-Forwarded-For", "X-Real-Ip":
+	default:
+		return fmt.Errorf(`TrustedProxyHeader must be "X-Forwarded-For" or "X-Real-IP", got: %q`, c.TrustedProxyHeader)
+	}
+
+	if len(c.SessionSecret) < storage.MinSessionKeyLength {
+		return fmt.Errorf("SessionSecret must be set in the config to a random string of at least %v characters", storage.MinSessionKeyLength)
+	}
+
+	db, err := storage.NewSqliteStorage(c.DB, []byte(c.SessionSecret))
 	if err != nil {
 		return err
 	}
 
-	sessionService := service.NewSessionService(&db)
+	sessionService, err := newSessionService(c, &db)
+	if err != nil {
+		return err
+	}
 	sharesService := service.NewSharesService(&db)
 
 	var logger log.Logger = &log.ConsoleLogger{}
@@ -41,21 +109,117 @@ func run() error {
 		logger = log.NewSyslogLogger()
 	}
 
+	go sessionService.CollectExpiredSessions(time.Duration(c.SessionGCInterval), logger)
+
 	a := app.NewApplication(app.OAuth{
 		TokenURL:     "https://github.com/login/oauth/access_token",
 		ClientID:     c.ClientID,
 		ClientSecret: c.ClientSecret,
-	}, logger, &sessionService, &sharesService)
+	}, publicURL, c.TrustedProxyHeader, logger, sessionService, &sharesService)
 
 	return a.Start(c.Addr)
 }
 
+type sessionService interface {
+	app.SessionService
+	CollectExpiredSessions(interval time.Duration, logger log.Logger)
+}
+
+// newSessionService creates the session service of the SessionBackend.
+func newSessionService(c *Config, db *storage.SqliteStorage) (sessionService, error) {
+	options := service.SessionOptions{
+		AbsoluteTimeout: time.Duration(c.SessionAbsoluteTimeout),
+		IdleTimeout:     time.Duration(c.SessionIdleTimeout),
+	}
+
+	switch c.SessionBackend {
+	case "", "sqlite":
+		s := service.NewSessionService(db, options)
+		return &s, nil
+	case "signed":
+		o := service.SignedSessionOptions{
+			SessionOptions: options,
+			Keys:           [][]byte{[]byte(c.SessionSecret)},
+		}
+		for _, v := range c.SessionPreviousSecrets {
+			o.Keys = append(o.Keys, []byte(v))
+		}
+		if c.SessionRevocation {
+			o.Revocations = db
+		}
+		return service.NewSignedSessionService(o)
+	default:
+		return nil, fmt.Errorf("unknown SessionBackend: %q", c.SessionBackend)
+	}
+}
+
 type Config struct {
 	ClientSecret string
 	ClientID     string
 	Syslog       bool
 	Addr         string
 	DB           string
+
+	// PublicURL is the URL (scheme and host, e.g. https://charts.example.com) that the
+	// server is reachable at, used for the links in share page metadata (they are
+	// omitted without it).
+	PublicURL string
+
+	// TrustedProxyHeader is the header that the address of the client (shown in
+	// the list of sessions) is taken from, when the server runs behind a reverse
+	// proxy: "X-Forwarded-For" (the last entry, appended by the proxy) or "X-Real-IP".
+	// Without it the address of the connection is used. Set it only when the proxy
+	// sets (or appends to) the header, otherwise clients can spoof their address.
+	TrustedProxyHeader string
+
+	// SessionBackend is either "sqlite" (default), sessions stored in the database,
+	// or "signed", stateless sessions signed with SessionSecret.
+	SessionBackend string
+
+	// SessionSecret is a random string of at least 32 characters, the key of the hashes
+	// of session ids stored in the database, or the key that signs "signed" sessions.
+	// It is required, the server does not start without it. Changing it logs out all
+	// users (see SessionPreviousSecrets). Upgrading a database created before session
+	// ids were hashed (schema migration 8) removes all existing sessions, so all users
+	// have to login again.
+	SessionSecret string
+
+	// SessionPreviousSecrets are former values of SessionSecret, that still verify
+	// "signed" sessions, so that the secret can be rotated without logging out users.
+	SessionPreviousSecrets []string
+
+	// SessionRevocation enables revocation of "signed" sessions (logout, revoking sessions),
+	// revoked sessions are stored in the database. Without it, signed sessions are valid
+	// until they expire. Revocations are cached in memory and reloaded every SessionGCInterval,
+	// so with multiple servers sharing the database, revocations made by one server are
+	// ignored by the others for up to SessionGCInterval.
+	SessionRevocation bool
+
+	// Session timeouts (see service.SessionOptions) and the interval
+	// of removing expired sessions from the database.
+	SessionAbsoluteTimeout Duration
+	SessionIdleTimeout     Duration
+	SessionGCInterval      Duration
+}
+
+// Duration is a time.Duration encoded in JSON as a
+// string accepted by time.ParseDuration, e.g. "168h".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if v <= 0 {
+		return fmt.Errorf("duration must be positive: %q", s)
+	}
+	*d = Duration(v)
+	return nil
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -64,8 +228,12 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
+	sessionOptions := service.DefaultSessionOptions()
 	c := &Config{
-		Addr: "127.0.0.1:8888",
+		Addr:                   "127.0.0.1:8888",
+		SessionAbsoluteTimeout: Duration(sessionOptions.AbsoluteTimeout),
+		SessionIdleTimeout:     Duration(sessionOptions.IdleTimeout),
+		SessionGCInterval:      Duration(time.Hour),
 	}
 
 	if err := json.NewDecoder(f).Decode(c); err != nil {
@@ -0,0 +1,132 @@
+package render
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+// GIFOptions controls an animated rendering of frames.
+type GIFOptions struct {
+	Options
+
+	// Delay is the time that every frame is shown, it is rounded
+	// down to hundredths of a second (the GIF delay unit).
+	Delay time.Duration
+
+	// Loops is the number of times the animation is played, zero means forever.
+	Loops int
+}
+
+// DefaultGIFOptions returns options that show every frame for half
+// a second and play the animation forever.
+func DefaultGIFOptions() GIFOptions {
+	return GIFOptions{
+		Options: DefaultOptions(),
+		Delay:   500 * time.Millisecond,
+	}
+}
+
+var (
+	errInvalidGIFOptions = errors.New("invalid gif options")
+
+	// ErrGIFTooLarge is returned by GIF when the frames exceed the pixel budget.
+	ErrGIFTooLarge = errors.New("gif too large")
+)
+
+const (
+	minGIFDelay = 20 * time.Millisecond
+	maxGIFDelay = 10 * time.Second
+	maxGIFLoops = 1000
+
+	// maxGIFPixels limits the sum of pixels of all frames (every
+	// pixel of a frame takes a byte of memory until it is encoded).
+	maxGIFPixels = 16 << 20
+)
+
+// Validate reports whether the options are in the supported range.
+func (o *GIFOptions) Validate() error {
+	if o.Delay < minGIFDelay || o.Delay > maxGIFDelay || o.Loops < 0 || o.Loops > maxGIFLoops {
+		return errInvalidGIFOptions
+	}
+	return o.Options.Validate()
+}
+
+// GIF writes the frames (see chart.ValidateFrames) as an animated GIF image,
+// every frame is drawn the same as by Image. It returns ErrGIFTooLarge when
+// the frames have more than maxGIFPixels pixels in total.
+func GIF(w io.Writer, frames []*chart.Chart, o *GIFOptions) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	if err := chart.ValidateFrames(frames); err != nil {
+		return err
+	}
+
+	noLabels := o.Options
+	noLabels.Labels = false
+
+	grids := make([]grid, len(frames))
+	pixels := 0
+	for i, c := range frames {
+		g, err := newGrid(c, &noLabels)
+		if err != nil {
+			return err
+		}
+		if pixels += g.width * g.height; pixels > maxGIFPixels {
+			return ErrGIFTooLarge
+		}
+		grids[i] = g
+	}
+
+	// Frames only use colors of the theme, so they are drawn directly
+	// with the palette indexes, without any quantization.
+	palette := color.Palette{o.Theme.Background}
+	for _, v := range o.Theme.Levels {
+		palette = append(palette, v)
+	}
+
+	anim := gif.GIF{
+		Image: make([]*image.Paletted, len(frames)),
+		Delay: make([]int, len(frames)),
+	}
+
+	// LoopCount 0 loops forever, -1 shows the frames once
+	// and n > 0 shows them n+1 times.
+	switch o.Loops {
+	case 0:
+		anim.LoopCount = 0
+	case 1:
+		anim.LoopCount = -1
+	default:
+		anim.LoopCount = o.Loops - 1
+	}
+
+	for i, g := range grids {
+		anim.Image[i] = palettedFrame(&g, palette, o.CellSize)
+		anim.Delay[i] = int(o.Delay / (10 * time.Millisecond))
+	}
+
+	return gif.EncodeAll(w, &anim)
+}
+
+// palettedFrame draws the cells of the grid, palette[0] is the background
+// and palette[level+1] is the color of the level.
+func palettedFrame(g *grid, palette color.Palette, cellSize int) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, g.width, g.height), palette)
+	for _, v := range g.cells {
+		idx := uint8(v.level) + 1
+		for y := v.y; y < v.y+cellSize; y++ {
+			row := img.Pix[img.PixOffset(v.x, y):][:cellSize]
+			for x := range row {
+				row[x] = idx
+			}
+		}
+	}
+	return img
+}
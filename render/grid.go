@@ -0,0 +1,120 @@
+package render
+
+import (
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+// grid is the geometry of a rendered chart, shared by all image formats.
+type grid struct {
+	width, height int
+	cells         []cell
+	monthLabels   []label
+	weekdayLabels []label
+	fontSize      int
+}
+
+type cell struct {
+	x, y  int
+	day   time.Time
+	level chart.Level
+}
+
+type label struct {
+	x, y int // baseline start
+	text string
+}
+
+func newGrid(c *chart.Chart, o *Options) (grid, error) {
+	l, err := c.Layout(o.WeekStart)
+	if err != nil {
+		return grid{}, err
+	}
+
+	var (
+		g       grid
+		step    = o.CellSize + o.CellGap
+		padding = o.CellSize
+		left    = padding
+		top     = padding
+	)
+
+	if o.Labels {
+		g.fontSize = o.CellSize + o.CellSize/5
+		left += 3 * g.fontSize
+		top += g.fontSize + o.CellGap
+	}
+
+	weeks := l.Weeks()
+
+	g.width = left + weeks*step - o.CellGap + padding
+	g.height = top + 7*step - o.CellGap + padding
+
+	g.cells = make([]cell, c.Len())
+	for i := range g.cells {
+		day := c.Day(i)
+		week, row := l.Position(i)
+		g.cells[i] = cell{
+			x:     left + week*step,
+			y:     top + row*step,
+			day:   day,
+			level: c.Level(day),
+		}
+	}
+
+	if o.Labels {
+		for _, v := range monthLabels(c, l, 3) {
+			g.monthLabels = append(g.monthLabels, label{
+				x:    left + v.week*step,
+				y:    top - o.CellGap - g.fontSize/4,
+				text: v.month.String()[:3],
+			})
+		}
+
+		for _, weekday := range labeledWeekdays {
+			g.weekdayLabels = append(g.weekdayLabels, label{
+				x:    padding,
+				y:    top + l.Row(weekday)*step + o.CellSize - (o.CellSize-g.fontSize*3/4)/2,
+				text: weekday.String()[:3],
+			})
+		}
+	}
+
+	return g, nil
+}
+
+// labeledWeekdays are the weekdays with a label, the same as on the github calendar.
+var labeledWeekdays = []time.Weekday{time.Monday, time.Wednesday, time.Friday}
+
+type monthLabel struct {
+	week  int
+	month time.Month
+}
+
+// monthLabels returns the weeks (columns) that the months of the chart are labeled on,
+// labels that are less than minWeeks after the previous one are skipped, so that they
+// do not overlap.
+func monthLabels(c *chart.Chart, l chart.Layout, minWeeks int) []monthLabel {
+	var labels []monthLabel
+	lastWeek := -minWeeks
+	for i := 0; i < c.Len(); i++ {
+		day := c.Day(i)
+		// Label the month of the first day and every month that starts in the chart.
+		if i != 0 && day.Day() != 1 {
+			continue
+		}
+		week, row := l.Position(i)
+		// Months that start in the middle of a week are labeled on the
+		// next week, so that the label is above the days of the month.
+		if row != 0 && i != 0 {
+			week++
+		}
+		if week-lastWeek < minWeeks || week >= l.Weeks() {
+			continue
+		}
+		lastWeek = week
+		labels = append(labels, monthLabel{week: week, month: day.Month()})
+	}
+	return labels
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+// ErrPNGTooLarge is returned by Image (and PNG) when the image would
+// have more than maxPNGPixels pixels.
+var ErrPNGTooLarge = errors.New("png image too large")
+
+// maxPNGPixels limits the memory used by Image (4 bytes per pixel).
+const maxPNGPixels = 3 << 20
+
+// Image draws the chart as an image. Text is not drawn, so
+// the Labels option is ignored.
+func Image(c *chart.Chart, o *Options) (*image.RGBA, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	noLabels := *o
+	noLabels.Labels = false
+	g, err := newGrid(c, &noLabels)
+	if err != nil {
+		return nil, err
+	}
+	if g.width*g.height > maxPNGPixels {
+		return nil, ErrPNGTooLarge
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, g.width, g.height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(o.Theme.Background), image.Point{}, draw.Src)
+
+	var levels [chart.MaxLevel + 1]*image.Uniform
+	for i, v := range o.Theme.Levels {
+		levels[i] = image.NewUniform(v)
+	}
+
+	for _, v := range g.cells {
+		r := image.Rect(v.x, v.y, v.x+o.CellSize, v.y+o.CellSize)
+		draw.Draw(img, r, levels[v.level], image.Point{}, draw.Src)
+	}
+
+	return img, nil
+}
+
+// PNG writes the chart as a PNG image, see Image.
+func PNG(w io.Writer, c *chart.Chart, o *Options) error {
+	img, err := Image(c, o)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,93 @@
+// Package render draws charts as github-like contribution calendars.
+package render
+
+import (
+	"errors"
+	"image/color"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+// Theme is a set of colors used while rendering.
+type Theme struct {
+	Background color.RGBA
+	Text       color.RGBA
+
+	// Levels contains the cell color of each intensity level.
+	Levels [chart.MaxLevel + 1]color.RGBA
+}
+
+var (
+	// LightTheme has the colors of the github light mode calendar.
+	LightTheme = Theme{
+		Background: color.RGBA{0xff, 0xff, 0xff, 0xff},
+		Text:       color.RGBA{0x57, 0x60, 0x6a, 0xff},
+		Levels: [chart.MaxLevel + 1]color.RGBA{
+			{0xeb, 0xed, 0xf0, 0xff},
+			{0x9b, 0xe9, 0xa8, 0xff},
+			{0x40, 0xc4, 0x63, 0xff},
+			{0x30, 0xa1, 0x4e, 0xff},
+			{0x21, 0x6e, 0x39, 0xff},
+		},
+	}
+
+	// DarkTheme has the colors of the github dark mode calendar.
+	DarkTheme = Theme{
+		Background: color.RGBA{0x0d, 0x11, 0x17, 0xff},
+		Text:       color.RGBA{0x7d, 0x85, 0x90, 0xff},
+		Levels: [chart.MaxLevel + 1]color.RGBA{
+			{0x16, 0x1b, 0x22, 0xff},
+			{0x0e, 0x44, 0x29, 0xff},
+			{0x00, 0x6d, 0x32, 0xff},
+			{0x26, 0xa6, 0x41, 0xff},
+			{0x39, 0xd3, 0x53, 0xff},
+		},
+	}
+)
+
+// Options controls the size and look of a rendered chart.
+type Options struct {
+	// CellSize is the width and height of a single day, in pixels.
+	CellSize int
+
+	// CellGap is the space between two adjacent days, in pixels.
+	CellGap int
+
+	// Labels enables month and weekday labels.
+	Labels bool
+
+	// WeekStart is the first weekday of every week (column), either
+	// sunday (the zero value, as on github) or monday (GitLab, Gitea).
+	WeekStart time.Weekday
+
+	Theme Theme
+}
+
+// DefaultOptions returns options that mimic the github calendar.
+func DefaultOptions() Options {
+	return Options{
+		CellSize: 10,
+		CellGap:  3,
+		Labels:   true,
+		Theme:    LightTheme,
+	}
+}
+
+var errInvalidOptions = errors.New("invalid render options")
+
+const (
+	maxCellSize = 64
+	maxCellGap  = 32
+)
+
+// Validate reports whether the options are in the supported range.
+func (o *Options) Validate() error {
+	if o.CellSize < 1 || o.CellSize > maxCellSize || o.CellGap < 0 || o.CellGap > maxCellGap {
+		return errInvalidOptions
+	}
+	if o.WeekStart != time.Sunday && o.WeekStart != time.Monday {
+		return errInvalidOptions
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+func TestSVG(t *testing.T) {
+	c, err := chart.New(2023)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(time.Date(2023, time.May, 5, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	o := DefaultOptions()
+	var buf bytes.Buffer
+	if err := SVG(&buf, c, &o); err != nil {
+		t.Fatal(err)
+	}
+
+	svg := buf.String()
+	d := xml.NewDecoder(strings.NewReader(svg))
+	rects := 0
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		if v, ok := tok.(xml.StartElement); ok && v.Name.Local == "rect" {
+			rects++
+		}
+	}
+
+	// Background and one rect per day.
+	if rects != 1+365 {
+		t.Errorf("got %v rects, want: %v", rects, 1+365)
+	}
+
+	if !strings.Contains(svg, hexColor(o.Theme.Levels[chart.MaxLevel])) {
+		t.Errorf("set day is not rendered with MaxLevel color")
+	}
+
+	o.CellSize = 0
+	if err := SVG(&buf, c, &o); err == nil {
+		t.Errorf("SVG with zero CellSize succeeded")
+	}
+}
+
+func TestPNG(t *testing.T) {
+	c, err := chart.New(2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if err := c.SetLevel(day, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	o := DefaultOptions()
+	var buf bytes.Buffer
+	if err := PNG(&buf, c, &o); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2024-01-01 is a monday, so it is in the first column and in the second row.
+	x := o.CellSize + o.CellSize/2
+	y := o.CellSize + o.CellSize + o.CellGap + o.CellSize/2
+	if got := color.RGBAModel.Convert(img.At(x, y)); got != o.Theme.Levels[2] {
+		t.Errorf("pixel (%v, %v) = %v, want: %v", x, y, got, o.Theme.Levels[2])
+	}
+
+	// With weeks starting on monday, it is in the first row.
+	o.WeekStart = time.Monday
+	img, err = Image(c, &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y = o.CellSize + o.CellSize/2
+	if got := color.RGBAModel.Convert(img.At(x, y)); got != o.Theme.Levels[2] {
+		t.Errorf("monday week start: pixel (%v, %v) = %v, want: %v", x, y, got, o.Theme.Levels[2])
+	}
+
+	o.CellSize = maxCellSize
+	o.CellGap = maxCellGap
+	if err := PNG(&buf, c, &o); err != ErrPNGTooLarge {
+		t.Errorf("PNG with maximum cell size and gap: %v, want: %v", err, ErrPNGTooLarge)
+	}
+}
+
+func TestSVGRange(t *testing.T) {
+	c, err := chart.NewRange(time.Date(2023, time.October, 16, 0, 0, 0, 0, time.UTC), 53)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(c.Start()); err != nil {
+		t.Fatal(err)
+	}
+
+	o := DefaultOptions()
+	var buf bytes.Buffer
+	if err := SVG(&buf, c, &o); err != nil {
+		t.Fatal(err)
+	}
+
+	svg := buf.String()
+	if n := strings.Count(svg, "<rect"); n != 1+53*7 {
+		t.Errorf("got %v rects, want: %v", n, 1+53*7)
+	}
+	// The range starts on a monday, so it spans 54 (partial) weeks.
+	if !strings.Contains(svg, ">Oct<") || !strings.Contains(svg, ">Jan<") {
+		t.Errorf("month labels of the range are missing")
+	}
+}
+
+func TestGIF(t *testing.T) {
+	var frames []*chart.Chart
+	for i := 0; i < 3; i++ {
+		c, err := chart.New(2024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.SetLevel(time.Date(2024, time.January, 1+i, 0, 0, 0, 0, time.UTC), 2); err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, c)
+	}
+
+	o := DefaultGIFOptions()
+	o.Delay = 250 * time.Millisecond
+	o.Loops = 2
+	var buf bytes.Buffer
+	if err := GIF(&buf, frames, &o); err != nil {
+		t.Fatal(err)
+	}
+
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anim.Image) != 3 || anim.Delay[0] != 25 || anim.LoopCount != 1 {
+		t.Errorf("got %v frames, delay: %v, loop count: %v, want: 3 frames, delay: 25, loop count: 1", len(anim.Image), anim.Delay[0], anim.LoopCount)
+	}
+
+	// 2024-01-02 is a tuesday, in the first column and third row.
+	x := o.CellSize + o.CellSize/2
+	y := o.CellSize + 2*(o.CellSize+o.CellGap) + o.CellSize/2
+	if got := color.RGBAModel.Convert(anim.Image[1].At(x, y)); got != o.Theme.Levels[2] {
+		t.Errorf("frame 1 pixel (%v, %v) = %v, want: %v", x, y, got, o.Theme.Levels[2])
+	}
+	if got := color.RGBAModel.Convert(anim.Image[0].At(x, y)); got != o.Theme.Levels[0] {
+		t.Errorf("frame 0 pixel (%v, %v) = %v, want: %v", x, y, got, o.Theme.Levels[0])
+	}
+
+	o.Delay = 0
+	if err := GIF(&buf, frames, &o); err == nil {
+		t.Error("GIF with zero delay succeeded")
+	}
+
+	o = DefaultGIFOptions()
+	o.CellSize = maxCellSize
+	for len(frames) < 32 {
+		frames = append(frames, frames[0])
+	}
+	if err := GIF(&buf, frames, &o); err != ErrGIFTooLarge {
+		t.Errorf("GIF of 32 large frames: %v, want: %v", err, ErrGIFTooLarge)
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	c, err := chart.New(2023)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2023-01-01 is a sunday, 2023-01-09 a monday of the second week.
+	if err := c.Set(time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetLevel(time.Date(2023, time.January, 9, 0, 0, 0, 0, time.UTC), 2); err != nil {
+		t.Fatal(err)
+	}
+
+	o := DefaultTerminalOptions()
+	o.ASCII = true
+	o.Colors = false
+	var buf bytes.Buffer
+	if err := Terminal(&buf, c, &o); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("got %v lines, want: 8:\n%v", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "    Jan       Feb") {
+		t.Errorf("month header = %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "    # . . .") {
+		t.Errorf("sunday line = %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "Mon . + . .") {
+		t.Errorf("monday line = %q", lines[2])
+	}
+
+	o.Colors = true
+	o.Labels = false
+	buf.Reset()
+	if err := Terminal(&buf, c, &o); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), ansiColor(o.Theme.Levels[chart.MaxLevel])+"#"+ansiReset) {
+		t.Errorf("colored output starts with %q", buf.String()[:32])
+	}
+}
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+// SVG writes the chart as an SVG image.
+func SVG(w io.Writer, c *chart.Chart, o *Options) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	g, err := newGrid(c, o)
+	if err != nil {
+		return err
+	}
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%v" height="%v" viewBox="0 0 %[1]v %[2]v">`+"\n", g.width, g.height)
+	fmt.Fprintf(b, `<rect width="100%%" height="100%%" fill="%v"/>`+"\n", hexColor(o.Theme.Background))
+
+	if o.Labels {
+		fmt.Fprintf(b, `<g font-family="-apple-system, BlinkMacSystemFont, 'Segoe UI', Helvetica, Arial, sans-serif" font-size="%v" fill="%v">`+"\n", g.fontSize, hexColor(o.Theme.Text))
+		for _, v := range g.monthLabels {
+			fmt.Fprintf(b, `<text x="%v" y="%v">%v</text>`+"\n", v.x, v.y, v.text)
+		}
+		for _, v := range g.weekdayLabels {
+			fmt.Fprintf(b, `<text x="%v" y="%v">%v</text>`+"\n", v.x, v.y, v.text)
+		}
+		b.WriteString("</g>\n")
+	}
+
+	radius := o.CellSize / 5
+	for _, v := range g.cells {
+		fmt.Fprintf(b, `<rect x="%v" y="%v" width="%v" height="%[3]v" rx="%v" fill="%v"><title>%v</title></rect>`+"\n",
+			v.x, v.y, o.CellSize, radius, hexColor(o.Theme.Levels[v.level]), v.day.Format(time.DateOnly),
+		)
+	}
+
+	b.WriteString("</svg>\n")
+	return b.Flush()
+}
+
+// hexColor formats the color as #rrggbb, alpha is ignored.
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,128 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+// TerminalOptions controls a text rendering of a chart.
+type TerminalOptions struct {
+	// ASCII uses only ASCII characters, instead of Unicode block characters.
+	ASCII bool
+
+	// Colors draws every day in the color of its level (from Theme)
+	// using ANSI 24-bit color escape sequences, otherwise levels are
+	// drawn with characters of increasing density.
+	Colors bool
+
+	// Labels enables month headers and weekday labels.
+	Labels bool
+
+	// WeekStart is the first weekday of every week (column), either
+	// sunday (the zero value, as on github) or monday (GitLab, Gitea).
+	WeekStart time.Weekday
+
+	Theme Theme
+}
+
+// DefaultTerminalOptions returns options that draw colored Unicode blocks with labels.
+func DefaultTerminalOptions() TerminalOptions {
+	return TerminalOptions{
+		Colors: true,
+		Labels: true,
+		Theme:  LightTheme,
+	}
+}
+
+var (
+	unicodeLevels = [chart.MaxLevel + 1]string{"·", "░", "▒", "▓", "█"}
+	asciiLevels   = [chart.MaxLevel + 1]string{".", ":", "+", "*", "#"}
+)
+
+const (
+	unicodeColorCell = "■"
+	asciiColorCell   = "#"
+
+	// Every week is two characters wide, a day and a space.
+	terminalWeekWidth = 2
+
+	// Weekday labels are three characters wide, followed by a space.
+	terminalLabelWidth = 4
+)
+
+// Terminal writes the chart as text, every week is a column of
+// two characters and every weekday is a line.
+func Terminal(w io.Writer, c *chart.Chart, o *TerminalOptions) error {
+	l, err := c.Layout(o.WeekStart)
+	if err != nil {
+		return err
+	}
+
+	levels, colorCell := unicodeLevels, unicodeColorCell
+	if o.ASCII {
+		levels, colorCell = asciiLevels, asciiColorCell
+	}
+
+	b := bufio.NewWriter(w)
+
+	if o.Labels {
+		// Month names are three characters long, so labels need two weeks.
+		var header strings.Builder
+		header.WriteString(strings.Repeat(" ", terminalLabelWidth))
+		for _, v := range monthLabels(c, l, 2) {
+			pad := terminalLabelWidth + v.week*terminalWeekWidth - header.Len()
+			header.WriteString(strings.Repeat(" ", pad))
+			header.WriteString(v.month.String()[:3])
+		}
+		b.WriteString(strings.TrimRight(header.String(), " "))
+		b.WriteString("\n")
+	}
+
+	for row := 0; row < 7; row++ {
+		var line strings.Builder
+		if o.Labels {
+			label := "    "
+			for _, v := range labeledWeekdays {
+				if l.Row(v) == row {
+					label = v.String()[:3] + " "
+				}
+			}
+			line.WriteString(label)
+		}
+
+		for week := 0; week < l.Weeks(); week++ {
+			i, ok := l.Index(week, row)
+			if !ok {
+				line.WriteString("  ")
+				continue
+			}
+			level := c.Level(c.Day(i))
+			if o.Colors {
+				line.WriteString(ansiColor(o.Theme.Levels[level]))
+				line.WriteString(colorCell)
+				line.WriteString(ansiReset)
+			} else {
+				line.WriteString(levels[level])
+			}
+			line.WriteString(" ")
+		}
+
+		b.WriteString(strings.TrimRight(line.String(), " "))
+		b.WriteString("\n")
+	}
+
+	return b.Flush()
+}
+
+const ansiReset = "\x1b[0m"
+
+// ansiColor returns the escape sequence that sets the 24-bit foreground color.
+func ansiColor(c color.RGBA) string {
+	return fmt.Sprintf("\x1b[38;2;%v;%v;%vm", c.R, c.G, c.B)
+}
@@ -0,0 +1,146 @@
+package reproduce
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+// ApplyOptions are the options of Apply.
+type ApplyOptions struct {
+	Options
+
+	// Dir is the path of the git repository, empty means the current directory.
+	Dir string
+
+	// DryRun only reports the days that would be applied, without creating any commits.
+	DryRun bool
+}
+
+// AppliedDay is a day of the chart considered by Apply.
+type AppliedDay struct {
+	// Date is the calendar date of the day (midnight UTC).
+	Date time.Time
+
+	// Commits is the number of commits created on the day
+	// (or that would be created in a dry run), zero when skipped.
+	Commits int
+
+	// Skipped reports that the repository already has commits on the day.
+	Skipped bool
+}
+
+// Apply creates (empty) commits reproducing the chart, in the git repository
+// by invoking the git binary, the same way as a script generated by Script.
+// Days (in o.Location) that already have commits (authored on them, reachable
+// from HEAD) are skipped, so Apply can be safely re-run with the same chart.
+func Apply(c *chart.Chart, o *ApplyOptions) ([]AppliedDay, error) {
+	commits, err := o.commits(c)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := o.existingDays()
+	if err != nil {
+		return nil, err
+	}
+
+	var env []string
+	if o.AuthorName != "" {
+		env = append(env, "GIT_AUTHOR_NAME="+o.AuthorName, "GIT_COMMITTER_NAME="+o.AuthorName)
+	}
+	if o.AuthorEmail != "" {
+		env = append(env, "GIT_AUTHOR_EMAIL="+o.AuthorEmail, "GIT_COMMITTER_EMAIL="+o.AuthorEmail)
+	}
+
+	var days []AppliedDay
+	for _, v := range commits {
+		year, month, day := v.date.Date()
+		date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(date) {
+			days = append(days, AppliedDay{Date: date, Skipped: existing[date]})
+		}
+
+		d := &days[len(days)-1]
+		if d.Skipped {
+			continue
+		}
+		d.Commits++
+		if o.DryRun {
+			continue
+		}
+
+		when := v.date.Format(time.RFC3339)
+		commitEnv := append(env[:len(env):len(env)], "GIT_AUTHOR_DATE="+when, "GIT_COMMITTER_DATE="+when)
+		if _, err := o.git(commitEnv, "commit", "--quiet", "--allow-empty", "-m", v.message); err != nil {
+			return days, err
+		}
+	}
+
+	return days, nil
+}
+
+// existingDays returns the calendar dates (midnight UTC) in o.Location
+// of the author dates of all commits reachable from HEAD.
+func (o *ApplyOptions) existingDays() (map[time.Time]bool, error) {
+	// Fails when the directory is not a git repository.
+	if _, err := o.git(nil, "rev-parse", "--git-dir"); err != nil {
+		return nil, err
+	}
+
+	days := make(map[time.Time]bool)
+
+	// Exits with 1 when there are no commits yet.
+	if _, err := o.git(nil, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return days, nil
+		}
+		return nil, err
+	}
+
+	out, err := o.git(nil, "log", "--format=%aI", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	loc := o.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		date, err := time.Parse(time.RFC3339, s.Text())
+		if err != nil {
+			return nil, fmt.Errorf("unexpected git log output: %v", err)
+		}
+		year, month, day := date.In(loc).Date()
+		days[time.Date(year, month, day, 0, 0, 0, 0, time.UTC)] = true
+	}
+	return days, nil
+}
+
+// git runs git in o.Dir with the additional environment variables, a failure
+// is returned as an error (wrapping *exec.ExitError) with the stderr of git.
+func (o *ApplyOptions) git(env []string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = o.Dir
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) != 0 {
+			return nil, fmt.Errorf("git %v: %w: %s", args[0], err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git %v: %w", args[0], err)
+	}
+	return out, nil
+}
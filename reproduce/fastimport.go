@@ -0,0 +1,116 @@
+package reproduce
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+// FastImportOptions are the options of FastImport.
+type FastImportOptions struct {
+	Options
+
+	// Branch is the name of the created branch (without the refs/heads/ prefix).
+	Branch string
+
+	// File is the path of the file, that is changed by every commit.
+	File string
+}
+
+// DefaultFastImportOptions returns DefaultOptions, that create
+// commits on the "main" branch changing the "charts" file.
+func DefaultFastImportOptions() FastImportOptions {
+	return FastImportOptions{
+		Options: DefaultOptions(),
+		Branch:  "main",
+		File:    "charts",
+	}
+}
+
+var (
+	errInvalidBranch = errors.New("invalid branch name")
+	errInvalidFile   = errors.New("invalid file path")
+
+	// ErrMissingEmail is returned by FastImport when the AuthorEmail is empty, unlike
+	// Script and Apply it cannot fall back to the git config, and github does not
+	// count commits with an empty email.
+	ErrMissingEmail = errors.New("author email is required")
+)
+
+func (o *FastImportOptions) validate() error {
+	if o.AuthorEmail == "" {
+		return ErrMissingEmail
+	}
+
+	// This is more strict than git check-ref-format.
+	if o.Branch == "" || strings.HasPrefix(o.Branch, "-") || strings.HasPrefix(o.Branch, "/") ||
+		strings.HasSuffix(o.Branch, "/") || strings.HasSuffix(o.Branch, ".lock") ||
+		strings.Contains(o.Branch, "..") || strings.Contains(o.Branch, "//") || strings.Contains(o.Branch, "@{") ||
+		strings.ContainsAny(o.Branch, " ~^:?*[\\\x7f") || containsControl(o.Branch) {
+		return errInvalidBranch
+	}
+
+	// Quoted paths are not supported, so disallow everything that would require quoting.
+	if o.File == "" || strings.HasPrefix(o.File, "/") || strings.HasSuffix(o.File, "/") || strings.HasPrefix(o.File, "\"") ||
+		strings.Contains(o.File, "//") || containsControl(o.File) {
+		return errInvalidFile
+	}
+	for _, v := range strings.Split(o.File, "/") {
+		if v == "." || v == ".." || v == ".git" {
+			return errInvalidFile
+		}
+	}
+	return nil
+}
+
+func containsControl(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return r < ' ' }) != -1
+}
+
+// FastImport writes a git fast-import stream, that creates a branch with
+// commits reproducing the chart, it is meant to be imported into an
+// empty repository. The AuthorEmail is required (see ErrMissingEmail).
+func FastImport(w io.Writer, c *chart.Chart, o *FastImportOptions) error {
+	if err := o.validate(); err != nil {
+		return err
+	}
+
+	commits, err := o.commits(c)
+	if err != nil {
+		return err
+	}
+
+	b := bufio.NewWriter(&limitWriter{w: w, n: maxOutputSize})
+
+	// With the done feature, git fast-import fails on truncated streams.
+	b.WriteString("feature done\n")
+
+	ident := fmt.Sprintf("<%v>", o.AuthorEmail)
+	if o.AuthorName != "" {
+		ident = o.AuthorName + " " + ident
+	}
+
+	writeData := func(data string) {
+		fmt.Fprintf(b, "data %v\n%v\n", len(data), data)
+	}
+
+	for i, v := range commits {
+		when := v.date.Format("-0700")
+		fmt.Fprintf(b, "commit refs/heads/%v\n", o.Branch)
+		fmt.Fprintf(b, "mark :%v\n", i+1)
+		fmt.Fprintf(b, "author %v %v %v\n", ident, v.date.Unix(), when)
+		fmt.Fprintf(b, "committer %v %v %v\n", ident, v.date.Unix(), when)
+		writeData(v.message)
+		fmt.Fprintf(b, "M 100644 inline %v\n", o.File)
+		writeData(v.date.Format(time.RFC3339) + "\n")
+		b.WriteString("\n")
+	}
+
+	b.WriteString("done\n")
+	return b.Flush()
+}
@@ -0,0 +1,138 @@
+// Package reproduce generates git commits that reproduce a chart
+// on the github contribution calendar.
+package reproduce
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+// Options are common to all generators.
+type Options struct {
+	// Message is the commit message, with the placeholders: {date} (YYYY-MM-DD),
+	// {n} (the number of the commit in the day, starting from 1) and {level}
+	// (the intensity level of the day) replaced in every commit.
+	Message string
+
+	// AuthorName and AuthorEmail are used both as author and committer,
+	// empty values leave them unset (so that the git config is used).
+	AuthorName  string
+	AuthorEmail string
+
+	// Location is the timezone of the commit dates, nil means UTC.
+	Location *time.Location
+
+	// CommitsPerDay is the number of commits created on a day with
+	// chart.MaxLevel, days with lower levels get proportionally
+	// fewer commits, but always at least one.
+	CommitsPerDay int
+}
+
+// DefaultOptions returns options that create a single
+// commit per day with the message "charts".
+func DefaultOptions() Options {
+	return Options{
+		Message:       "charts",
+		CommitsPerDay: 1,
+	}
+}
+
+const (
+	maxCommitsPerDay = 100
+	maxMessageLength = 1024
+
+	// maxOutputSize limits the size of the generated scripts and streams.
+	maxOutputSize = 32 << 20
+)
+
+var (
+	errInvalidCommitsPerDay = errors.New("commits per day out of range")
+	errMessageTooLong       = errors.New("commit message too long")
+	errOutputTooLarge       = errors.New("generated output too large")
+	errInvalidAuthor        = errors.New("author name and email must not contain '<', '>' or new lines")
+)
+
+// commit is a single commit generated from a chart.
+type commit struct {
+	date    time.Time
+	message string
+}
+
+func (o *Options) validate() error {
+	if o.CommitsPerDay < 1 || o.CommitsPerDay > maxCommitsPerDay {
+		return errInvalidCommitsPerDay
+	}
+	if len(o.Message) > maxMessageLength {
+		return errMessageTooLong
+	}
+	if strings.ContainsAny(o.AuthorName+o.AuthorEmail, "<>\n\r\x00") {
+		return errInvalidAuthor
+	}
+	return nil
+}
+
+// commitsOnDay returns the number of commits on a day with the level.
+func (o *Options) commitsOnDay(level chart.Level) int {
+	n := (o.CommitsPerDay*int(level) + int(chart.MaxLevel) - 1) / int(chart.MaxLevel)
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// commits returns all commits (in chronological order) that reproduce the chart.
+// Commits are created at noon (in o.Location), one minute apart.
+func (o *Options) commits(c *chart.Chart) ([]commit, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	loc := o.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var (
+		commits []commit
+		size    int
+	)
+
+	for _, day := range c.Days() {
+		level := c.Level(day)
+		year, month, d := day.Date()
+		for i := 0; i < o.commitsOnDay(level); i++ {
+			date := time.Date(year, month, d, 12, i, 0, 0, loc)
+			msg := strings.NewReplacer(
+				"{date}", date.Format(time.DateOnly),
+				"{n}", strconv.Itoa(i+1),
+				"{level}", strconv.Itoa(int(level)),
+			).Replace(o.Message)
+
+			if size += len(msg); size > maxOutputSize {
+				return nil, errOutputTooLarge
+			}
+			commits = append(commits, commit{date: date, message: msg})
+		}
+	}
+
+	return commits, nil
+}
+
+// limitWriter fails with errOutputTooLarge, when more than n bytes are written.
+type limitWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *limitWriter) Write(b []byte) (int, error) {
+	if len(b) > l.n {
+		return 0, errOutputTooLarge
+	}
+	l.n -= len(b)
+	return l.w.Write(b)
+}
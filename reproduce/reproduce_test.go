@@ -0,0 +1,317 @@
+package reproduce
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+func testChart(t *testing.T) *chart.Chart {
+	c, err := chart.New(2023)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetLevel(time.Date(2023, time.June, 15, 0, 0, 0, 0, time.UTC), 2); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// gitRepo creates an empty git repository, it skips the test when git is not installed.
+func gitRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init", "--quiet", dir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v: %s", err, out)
+	}
+	return dir
+}
+
+func gitLog(t *testing.T, dir string) []string {
+	cmd := exec.Command("git", "log", "--reverse", "--format=%aI|%cI|%an|%ae|%B%x00")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git log failed: %v: %s", err, out)
+	}
+	var res []string
+	for _, v := range strings.Split(string(out), "\x00") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func TestScript(t *testing.T) {
+	for _, shell := range []Shell{Bash, POSIX} {
+		t.Run(shell.String(), func(t *testing.T) {
+			bin, err := exec.LookPath(shell.String())
+			if err != nil {
+				t.Skipf("%v not installed", shell)
+			}
+			dir := gitRepo(t)
+
+			o := ScriptOptions{
+				Options: Options{
+					Message:       `it's a "test" $HOME` + "`id`\\ {n}",
+					AuthorName:    "O'Brien",
+					AuthorEmail:   "ob@example.com",
+					Location:      time.FixedZone("", 2*3600),
+					CommitsPerDay: 4,
+				},
+				Shell: shell,
+			}
+
+			var buf bytes.Buffer
+			if err := Script(&buf, testChart(t), &o); err != nil {
+				t.Fatal(err)
+			}
+
+			script := filepath.Join(t.TempDir(), "script"+shell.Extension())
+			if err := os.WriteFile(script, buf.Bytes(), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := exec.Command(bin, script)
+			cmd.Dir = dir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("script failed: %v: %s", err, out)
+			}
+
+			want := []string{
+				"2023-01-01T12:00:00+02:00|2023-01-01T12:00:00+02:00|O'Brien|ob@example.com|it's a \"test\" $HOME`id`\\ 1",
+				"2023-01-01T12:01:00+02:00|2023-01-01T12:01:00+02:00|O'Brien|ob@example.com|it's a \"test\" $HOME`id`\\ 2",
+				"2023-01-01T12:02:00+02:00|2023-01-01T12:02:00+02:00|O'Brien|ob@example.com|it's a \"test\" $HOME`id`\\ 3",
+				"2023-01-01T12:03:00+02:00|2023-01-01T12:03:00+02:00|O'Brien|ob@example.com|it's a \"test\" $HOME`id`\\ 4",
+				"2023-06-15T12:00:00+02:00|2023-06-15T12:00:00+02:00|O'Brien|ob@example.com|it's a \"test\" $HOME`id`\\ 1",
+				"2023-06-15T12:01:00+02:00|2023-06-15T12:01:00+02:00|O'Brien|ob@example.com|it's a \"test\" $HOME`id`\\ 2",
+			}
+
+			got := gitLog(t, dir)
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("unexpected git log:\n%v\nwant:\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		shell Shell
+		in    string
+		out   string
+	}{
+		{Bash, `a'b`, `'a'\''b'`},
+		{POSIX, `$x`, `'$x'`},
+		{PowerShell, `it's`, `'it''s'`},
+		{PowerShell, "it’s", "'it’’s'"},
+		{Fish, `a\'b`, `'a\\\'b'`},
+	}
+	for _, v := range tests {
+		if got := quote(v.shell, v.in); got != v.out {
+			t.Errorf("quote(%v, %q) = %q, want: %q", v.shell, v.in, got, v.out)
+		}
+	}
+}
+
+func TestCommitMessage(t *testing.T) {
+	o := DefaultOptions()
+	o.Message = "{date} {n}/{level} {{.N}} {x}"
+	o.CommitsPerDay = 4
+
+	commits, err := o.commits(testChart(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, v := range commits {
+		got = append(got, v.message)
+	}
+	want := []string{
+		"2023-01-01 1/4 {{.N}} {x}",
+		"2023-01-01 2/4 {{.N}} {x}",
+		"2023-01-01 3/4 {{.N}} {x}",
+		"2023-01-01 4/4 {{.N}} {x}",
+		"2023-06-15 1/2 {{.N}} {x}",
+		"2023-06-15 2/2 {{.N}} {x}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected messages: %q, want: %q", got, want)
+	}
+
+	o.Message = strings.Repeat("a", maxMessageLength+1)
+	if _, err := o.commits(testChart(t)); err != errMessageTooLong {
+		t.Errorf("commits() with a too long message: %v, want: %v", err, errMessageTooLong)
+	}
+}
+
+func TestFastImport(t *testing.T) {
+	dir := gitRepo(t)
+
+	o := DefaultFastImportOptions()
+	o.AuthorName = "Test"
+	o.AuthorEmail = "test@example.com"
+	o.Message = "day {date}"
+	o.Location = time.FixedZone("", -5*3600)
+	o.CommitsPerDay = 2
+	o.Branch = "charts/main"
+	o.File = "dir/file"
+
+	var buf bytes.Buffer
+	if err := FastImport(&buf, testChart(t), &o); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("git", "fast-import", "--quiet")
+	cmd.Dir = dir
+	cmd.Stdin = &buf
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git fast-import failed: %v: %s", err, out)
+	}
+
+	cmd = exec.Command("git", "checkout", "--quiet", "charts/main")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git checkout failed: %v: %s", err, out)
+	}
+
+	want := []string{
+		"2023-01-01T12:00:00-05:00|2023-01-01T12:00:00-05:00|Test|test@example.com|day 2023-01-01",
+		"2023-01-01T12:01:00-05:00|2023-01-01T12:01:00-05:00|Test|test@example.com|day 2023-01-01",
+		"2023-06-15T12:00:00-05:00|2023-06-15T12:00:00-05:00|Test|test@example.com|day 2023-06-15",
+	}
+
+	got := gitLog(t, dir)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected git log:\n%v\nwant:\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	for _, v := range []string{"", "-x", "a b", "a..b", "a.lock", "a\nb"} {
+		o := DefaultFastImportOptions()
+		o.AuthorEmail = "test@example.com"
+		o.Branch = v
+		if err := FastImport(io.Discard, testChart(t), &o); err == nil {
+			t.Errorf("FastImport with branch %q succeeded", v)
+		}
+	}
+
+	for _, v := range []string{"", "/a", "a/", "../a", ".git/config", "\"a"} {
+		o := DefaultFastImportOptions()
+		o.AuthorEmail = "test@example.com"
+		o.File = v
+		if err := FastImport(io.Discard, testChart(t), &o); err == nil {
+			t.Errorf("FastImport with file %q succeeded", v)
+		}
+	}
+
+	o = DefaultFastImportOptions()
+	if err := FastImport(io.Discard, testChart(t), &o); err != ErrMissingEmail {
+		t.Errorf("FastImport without an email: %v, want: %v", err, ErrMissingEmail)
+	}
+
+	for _, v := range []string{"a>b@example.com", "a@example.com\ncommitter x <x>"} {
+		o := DefaultFastImportOptions()
+		o.AuthorEmail = v
+		if err := FastImport(io.Discard, testChart(t), &o); err == nil {
+			t.Errorf("FastImport with email %q succeeded", v)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	dir := gitRepo(t)
+	c := testChart(t)
+
+	o := ApplyOptions{
+		Options: Options{
+			Message:       "{n}",
+			AuthorName:    "test",
+			AuthorEmail:   "test@example.com",
+			Location:      time.FixedZone("", -5*3600),
+			CommitsPerDay: 2,
+		},
+		Dir:    dir,
+		DryRun: true,
+	}
+
+	jan1 := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	jun15 := time.Date(2023, time.June, 15, 0, 0, 0, 0, time.UTC)
+
+	days, err := Apply(c, &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AppliedDay{{Date: jan1, Commits: 2}, {Date: jun15, Commits: 1}}
+	if !reflect.DeepEqual(days, want) {
+		t.Fatalf("unexpected dry run days: %v, want: %v", days, want)
+	}
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD")
+	cmd.Dir = dir
+	if err := cmd.Run(); err == nil {
+		t.Fatal("dry run created commits")
+	}
+
+	// A commit on Jun 15 in o.Location, but on Jun 16 in UTC.
+	cmd = exec.Command("git", "commit", "--quiet", "--allow-empty", "-m", "existing")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_AUTHOR_DATE=2023-06-16T02:00:00Z", "GIT_COMMITTER_DATE=2023-06-16T02:00:00Z",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v: %s", err, out)
+	}
+
+	o.DryRun = false
+	days, err = Apply(c, &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []AppliedDay{{Date: jan1, Commits: 2}, {Date: jun15, Skipped: true}}
+	if !reflect.DeepEqual(days, want) {
+		t.Fatalf("unexpected days: %v, want: %v", days, want)
+	}
+
+	wantLog := []string{
+		"2023-06-16T02:00:00+00:00|2023-06-16T02:00:00+00:00|test|test@example.com|existing",
+		"2023-01-01T12:00:00-05:00|2023-01-01T12:00:00-05:00|test|test@example.com|1",
+		"2023-01-01T12:01:00-05:00|2023-01-01T12:01:00-05:00|test|test@example.com|2",
+	}
+	if got := gitLog(t, dir); strings.Join(got, "\n") != strings.Join(wantLog, "\n") {
+		t.Fatalf("unexpected git log:\n%v\nwant:\n%v", strings.Join(got, "\n"), strings.Join(wantLog, "\n"))
+	}
+
+	// Re-running is a no-op.
+	days, err = Apply(c, &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []AppliedDay{{Date: jan1, Skipped: true}, {Date: jun15, Skipped: true}}
+	if !reflect.DeepEqual(days, want) {
+		t.Fatalf("unexpected days after re-run: %v, want: %v", days, want)
+	}
+	if got := gitLog(t, dir); len(got) != len(wantLog) {
+		t.Fatalf("re-run created commits: %v", got)
+	}
+
+	if _, err := Apply(c, &ApplyOptions{Options: DefaultOptions(), Dir: t.TempDir()}); err == nil {
+		t.Fatal("unexpected success outside of a git repository")
+	}
+}
@@ -0,0 +1,147 @@
+package reproduce
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+// Shell is the target shell of a generated script.
+type Shell uint8
+
+const (
+	Bash Shell = iota
+	POSIX
+	PowerShell
+	Fish
+)
+
+var shellNames = [...]string{
+	Bash:       "bash",
+	POSIX:      "sh",
+	PowerShell: "powershell",
+	Fish:       "fish",
+}
+
+var shellExtensions = [...]string{
+	Bash:       ".sh",
+	POSIX:      ".sh",
+	PowerShell: ".ps1",
+	Fish:       ".fish",
+}
+
+var errUnknownShell = errors.New("unknown shell")
+
+// ParseShell parses the shell name ("bash", "sh", "powershell" or "fish").
+func ParseShell(name string) (Shell, error) {
+	for i, v := range shellNames {
+		if v == name {
+			return Shell(i), nil
+		}
+	}
+	return 0, errUnknownShell
+}
+
+func (s Shell) String() string {
+	return shellNames[s]
+}
+
+// Extension returns the script file extension (with the leading dot).
+func (s Shell) Extension() string {
+	return shellExtensions[s]
+}
+
+// ScriptOptions are the options of Script.
+type ScriptOptions struct {
+	Options
+	Shell Shell
+}
+
+// Script writes a script, that creates (empty) commits reproducing
+// the chart, when run inside of a git repository.
+func Script(w io.Writer, c *chart.Chart, o *ScriptOptions) error {
+	if o.Shell > Fish {
+		return errUnknownShell
+	}
+
+	commits, err := o.commits(c)
+	if err != nil {
+		return err
+	}
+
+	b := bufio.NewWriter(&limitWriter{w: w, n: maxOutputSize})
+
+	switch o.Shell {
+	case Bash:
+		b.WriteString("#!/usr/bin/env bash\nset -euo pipefail\n")
+	case POSIX:
+		b.WriteString("#!/bin/sh\nset -eu\n")
+	case PowerShell:
+		b.WriteString("$ErrorActionPreference = 'Stop'\n")
+	case Fish:
+		b.WriteString("#!/usr/bin/env fish\n")
+	}
+
+	b.WriteString("# Run inside of a git repository to reproduce the chart.\n")
+
+	setEnv := func(name, value string) {
+		switch o.Shell {
+		case PowerShell:
+			fmt.Fprintf(b, "$env:%v = %v\n", name, quote(o.Shell, value))
+		case Fish:
+			fmt.Fprintf(b, "set -gx %v %v\n", name, quote(o.Shell, value))
+		default:
+			fmt.Fprintf(b, "export %v=%v\n", name, quote(o.Shell, value))
+		}
+	}
+
+	if o.AuthorName != "" {
+		setEnv("GIT_AUTHOR_NAME", o.AuthorName)
+		setEnv("GIT_COMMITTER_NAME", o.AuthorName)
+	}
+	if o.AuthorEmail != "" {
+		setEnv("GIT_AUTHOR_EMAIL", o.AuthorEmail)
+		setEnv("GIT_COMMITTER_EMAIL", o.AuthorEmail)
+	}
+
+	for _, v := range commits {
+		date := v.date.Format(time.RFC3339)
+		setEnv("GIT_AUTHOR_DATE", date)
+		setEnv("GIT_COMMITTER_DATE", date)
+
+		fmt.Fprintf(b, "git commit --quiet --allow-empty -m %v", quote(o.Shell, v.message))
+		switch o.Shell {
+		case PowerShell:
+			b.WriteString("\nif ($LASTEXITCODE -ne 0) { exit $LASTEXITCODE }")
+		case Fish:
+			b.WriteString("; or exit 1")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.Flush()
+}
+
+// quote quotes the string as a single argument of the shell.
+func quote(s Shell, str string) string {
+	switch s {
+	case PowerShell:
+		// Single-quoted strings are verbatim, only quotes need to be doubled.
+		// PowerShell also treats the unicode quotation marks as quotes.
+		r := strings.NewReplacer("'", "''", "‘", "‘‘", "’", "’’", "‚", "‚‚", "‛", "‛‛")
+		return "'" + r.Replace(str) + "'"
+	case Fish:
+		// Inside of single quotes fish only interprets \' and \\.
+		r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+		return "'" + r.Replace(str) + "'"
+	default:
+		// POSIX single quotes can't contain a quote, so the string is
+		// closed, an escaped quote is added, and the string is reopened.
+		return "'" + strings.ReplaceAll(str, "'", `'\''`) + "'"
+	}
+}
@@ -6,54 +6,160 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/mateusz834/charts/log"
 	"github.com/mateusz834/charts/storage"
 )
 
 type SessionStorage interface {
 	StoreSession(s *storage.Session) error
-	IsSessionValid(s *storage.Session) error
+	GetSession(s *storage.Session) error
+	TouchSession(s *storage.Session) error
 	RemoveSession(s *storage.Session) error
+	RemoveExpiredSessions(createdBefore, lastSeenBefore time.Time) (int64, error)
+	GetUserSessions(githubUserID uint64) ([]storage.Session, error)
+	RemoveSessionByID(githubUserID uint64, id int64) error
+	RemoveUserSessions(githubUserID uint64) error
 }
 
+// SessionOptions controls the expiry of sessions.
+type SessionOptions struct {
+	// AbsoluteTimeout is the maximum lifetime of a session, since the login.
+	AbsoluteTimeout time.Duration
+
+	// IdleTimeout is the maximum time between two uses of a session,
+	// every use of a session extends its expiry (up to AbsoluteTimeout).
+	IdleTimeout time.Duration
+}
+
+// DefaultSessionOptions returns options of sessions that expire
+// after 30 days, or after 7 days without being used.
+func DefaultSessionOptions() SessionOptions {
+	return SessionOptions{
+		AbsoluteTimeout: 30 * 24 * time.Hour,
+		IdleTimeout:     7 * 24 * time.Hour,
+	}
+}
+
+// maxRenewInterval limits the time between updates of the last seen
+// time of a session, they are not updated on every use, to avoid
+// writing to the database on every request.
+const maxRenewInterval = time.Hour
+
 type SessionService struct {
 	storage SessionStorage
+	options SessionOptions
 }
 
-func NewSessionService(storage SessionStorage) SessionService {
+func NewSessionService(storage SessionStorage, options SessionOptions) SessionService {
 	return SessionService{
 		storage: storage,
+		options: options,
+	}
+}
+
+// ValidSession is a session validated by IsSessionValid.
+type ValidSession struct {
+	GithubUserID uint64
+
+	// Session is the validated session, or a new one that replaces
+	// it (the same session, but with an extended expiry).
+	Session string
+
+	// Expires is the time at which the session expires, unless used again.
+	Expires time.Time
+
+	// Renewed reports that the expiry of the session was extended, so the
+	// Session should be sent again to the client, with the new Expires.
+	Renewed bool
+}
+
+// expires returns the expiry of a session, last seen at lastSeenAt.
+func (o *SessionOptions) expires(createdAt, lastSeenAt time.Time) time.Time {
+	absolute := createdAt.Add(o.AbsoluteTimeout)
+	idle := lastSeenAt.Add(o.IdleTimeout)
+	if idle.Before(absolute) {
+		return idle
+	}
+	return absolute
+}
+
+// renewInterval returns the minimum time between updates of the last seen time of a session.
+func (o *SessionOptions) renewInterval() time.Duration {
+	if d := o.IdleTimeout / 10; d < maxRenewInterval {
+		return d
 	}
+	return maxRenewInterval
 }
 
-func (s *SessionService) NewSession(githubUserID uint64) (string, error) {
+const maxUserAgentLength = 256
+
+// NewSession creates a session, it returns the session and its expiry. The user
+// agent and the (approximate) ip of the client are shown in the list of sessions.
+func (s *SessionService) NewSession(githubUserID uint64, userAgent, ip string) (string, time.Time, error) {
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+
 	ses := storage.Session{
 		GithubUserID: githubUserID,
+		UserAgent:    userAgent,
+		IP:           ip,
 	}
 
 	if _, err := rand.Read(ses.SessionID[:]); err != nil {
-		return "", fmt.Errorf("failed to generate random session id: %v", err)
+		return "", time.Time{}, fmt.Errorf("failed to generate random session id: %v", err)
 	}
 
+	now := time.Now()
 	if err := s.storage.StoreSession(&ses); err != nil {
-		return "", fmt.Errorf("failed to store session: %v", err)
+		return "", time.Time{}, fmt.Errorf("failed to store session: %v", err)
 	}
 
-	return encodeSession(&ses), nil
+	return encodeSession(&ses), s.options.expires(now, now), nil
 }
 
-func (s *SessionService) IsSessionValid(session string) (uint64, error) {
+var errSessionExpired = errors.New("session expired")
+
+// IsSessionValid validates the session, expired sessions are removed. Sessions that
+// were not used for a while are renewed, so that they expire IdleTimeout after now.
+func (s *SessionService) IsSessionValid(session string) (ValidSession, error) {
 	ses, err := decodeSession(session)
 	if err != nil {
-		return 0, err
+		return ValidSession{}, err
 	}
-	if err := s.storage.IsSessionValid(ses); err != nil {
+	if err := s.storage.GetSession(ses); err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
-			return 0, PublicWrapperError{errors.New("session not found")}
+			return ValidSession{}, PublicWrapperError{errors.New("session not found")}
+		}
+		return ValidSession{}, err
+	}
+
+	now := time.Now()
+	valid := ValidSession{
+		GithubUserID: ses.GithubUserID,
+		Session:      session,
+		Expires:      s.options.expires(ses.CreatedAt, ses.LastSeenAt),
+	}
+
+	if !now.Before(valid.Expires) {
+		if err := s.storage.RemoveSession(ses); err != nil {
+			return ValidSession{}, err
+		}
+		return ValidSession{}, PublicWrapperError{errSessionExpired}
+	}
+
+	if now.Sub(ses.LastSeenAt) >= s.options.renewInterval() {
+		if err := s.storage.TouchSession(ses); err != nil {
+			return ValidSession{}, err
 		}
-		return 0, err
+		valid.Expires = s.options.expires(ses.CreatedAt, now)
+		valid.Renewed = true
 	}
-	return ses.GithubUserID, nil
+
+	return valid, nil
 }
 
 func (s *SessionService) RemoveSession(session string) error {
@@ -64,6 +170,96 @@ func (s *SessionService) RemoveSession(session string) error {
 	return s.storage.RemoveSession(ses)
 }
 
+// SessionInfo describes an active session of a user.
+type SessionInfo struct {
+	ID         int64
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	UserAgent  string
+	IP         string
+
+	// Current reports whether this is the session passed to GetUserSessions.
+	Current bool
+}
+
+// GetUserSessions returns all active sessions of the owner of the session, ordered
+// by the last seen time (the most recent first).
+func (s *SessionService) GetUserSessions(session string) ([]SessionInfo, error) {
+	current, err := decodeSession(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storage.GetSession(current); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, PublicWrapperError{errors.New("session not found")}
+		}
+		return nil, err
+	}
+
+	sessions, err := s.storage.GetUserSessions(current.GithubUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	res := make([]SessionInfo, 0, len(sessions))
+	for _, v := range sessions {
+		if !now.Before(s.options.expires(v.CreatedAt, v.LastSeenAt)) {
+			continue
+		}
+		res = append(res, SessionInfo{
+			ID:         v.ID,
+			CreatedAt:  v.CreatedAt,
+			LastSeenAt: v.LastSeenAt,
+			UserAgent:  v.UserAgent,
+			IP:         v.IP,
+			Current:    v.ID == current.ID,
+		})
+	}
+	return res, nil
+}
+
+// RevokeSession removes the session of the user with the ID (see SessionInfo.ID).
+func (s *SessionService) RevokeSession(githubUserID uint64, id int64) error {
+	if err := s.storage.RemoveSessionByID(githubUserID, id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return PublicWrapperError{errors.New("session not found")}
+		}
+		return err
+	}
+	return nil
+}
+
+// RemoveUserSessions removes all sessions of the user (logs out everywhere).
+func (s *SessionService) RemoveUserSessions(githubUserID uint64) error {
+	return s.storage.RemoveUserSessions(githubUserID)
+}
+
+// RemoveExpiredSessions removes all expired sessions.
+func (s *SessionService) RemoveExpiredSessions() (int64, error) {
+	now := time.Now()
+	return s.storage.RemoveExpiredSessions(now.Add(-s.options.AbsoluteTimeout), now.Add(-s.options.IdleTimeout))
+}
+
+// CollectExpiredSessions calls RemoveExpiredSessions every interval, it never returns.
+func (s *SessionService) CollectExpiredSessions(interval time.Duration, logger log.Logger) {
+	collectExpired(interval, logger, "expired sessions", s.RemoveExpiredSessions)
+}
+
+// collectExpired calls remove every interval and logs its result, it never returns.
+func collectExpired(interval time.Duration, logger log.Logger, what string, remove func() (int64, error)) {
+	for range time.Tick(interval) {
+		n, err := remove()
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to remove %v: %v", what, err))
+			continue
+		}
+		if n != 0 {
+			logger.Debug(fmt.Sprintf("removed %v %v", n, what))
+		}
+	}
+}
+
 func encodeSession(s *storage.Session) string {
 	bin := make([]byte, 8+32)
 	binary.BigEndian.PutUint64(bin[:8], s.GithubUserID)
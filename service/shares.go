@@ -4,6 +4,8 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/mateusz834/charts/chart"
 	"github.com/mateusz834/charts/storage"
@@ -62,10 +64,24 @@ type CreateShare struct {
 	CustomPath   bool
 	Path         string
 	EncodedChart string
+
+	// Timezone is the IANA timezone name or UTC offset of the
+	// share creator, it is optional.
+	Timezone string
+
+	// WeekStart is the week start ("sunday" or "monday") of the calendar
+	// that the chart was drawn for, empty means sunday.
+	WeekStart string
+
+	// EncodedFrames are optional animation frames shown after the chart,
+	// they must cover the same days as the chart.
+	EncodedFrames []string
 }
 
 var ErrPathUnavail = errors.New("path is not available")
 var ErrTooMuchShares = errors.New("you have created too much public shares 250/250")
+var errInvalidTimezone = errors.New("invalid timezone")
+var errTooManyFrames = fmt.Errorf("at most %v frames (including the chart) are allowed", chart.MaxFrames)
 
 type CreateShareError struct {
 	Type string
@@ -89,15 +105,49 @@ func (s *SharesService) CreateShare(req *CreateShare) (string, error) {
 		path = base64.RawURLEncoding.EncodeToString(pathBin)
 	}
 
-	chart, err := chart.Decode(req.EncodedChart)
+	c, version, err := chart.DecodeVersion(req.EncodedChart)
 	if err != nil {
 		return "", &CreateShareError{"chart", err}
 	}
 
+	if req.Timezone != "" {
+		if _, err := chart.ParseLocation(req.Timezone); err != nil {
+			return "", &CreateShareError{"timezone", PublicWrapperError{errInvalidTimezone}}
+		}
+	}
+
+	weekStart := time.Sunday
+	if req.WeekStart != "" {
+		weekStart, err = chart.ParseWeekStart(req.WeekStart)
+		if err != nil {
+			return "", &CreateShareError{"week_start", PublicWrapperError{err}}
+		}
+	}
+
+	var frames []*chart.Chart
+	if len(req.EncodedFrames) >= chart.MaxFrames {
+		return "", &CreateShareError{"frames", errTooManyFrames}
+	}
+	if len(req.EncodedFrames) != 0 {
+		frames = make([]*chart.Chart, len(req.EncodedFrames))
+		for i, v := range req.EncodedFrames {
+			if frames[i], err = chart.Decode(v); err != nil {
+				return "", &CreateShareError{"frames", fmt.Errorf("frame %v: %w", i, err)}
+			}
+		}
+		if err := chart.ValidateFrames(append([]*chart.Chart{c}, frames...)); err != nil {
+			return "", &CreateShareError{"frames", err}
+		}
+	}
+
 	avail, err := s.storage.CreateShare(&storage.Share{
 		GithubUserID: req.GithubUserID,
 		Path:         path,
-		Chart:        chart,
+		Chart:        c,
+		ChartVersion: version,
+		Timezone:     req.Timezone,
+		WeekStart:    weekStart,
+		Frames:       frames,
 	}, 250)
 
 	if err != nil {
@@ -117,22 +167,57 @@ func (s *SharesService) CreateShare(req *CreateShare) (string, error) {
 type Share struct {
 	GithubUserID uint64
 	Path         string
-	EncodedChart string
+	Chart        *chart.Chart
+
+	// ChartVersion is the encoding version that the share was created with.
+	ChartVersion chart.Version
+
+	// Timezone is the timezone of the share creator, empty when unknown.
+	Timezone string
+
+	// WeekStart is the first weekday of the calendar that the chart was drawn for.
+	WeekStart time.Weekday
+
+	// Frames are the animation frames shown after Chart.
+	Frames []*chart.Chart
 }
 
-func (s *SharesService) GetShare(path string) (*Share, error) {
-	share, err := s.storage.GetShare(path)
+// AllFrames returns the chart followed by the animation frames.
+func (s *Share) AllFrames() []*chart.Chart {
+	return append([]*chart.Chart{s.Chart}, s.Frames...)
+}
+
+// Location returns the timezone of the share creator,
+// or UTC when it is not known.
+func (s *Share) Location() *time.Location {
+	if s.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := chart.ParseLocation(s.Timezone)
 	if err != nil {
-		return nil, err
+		return time.UTC
 	}
-	encoded, err := chart.Encode(share.Chart)
+	return loc
+}
+
+// EncodedChart encodes the chart in the version that the share was created with.
+func (s *Share) EncodedChart() (string, error) {
+	return chart.EncodeVersion(s.Chart, s.ChartVersion)
+}
+
+func (s *SharesService) GetShare(path string) (*Share, error) {
+	share, err := s.storage.GetShare(path)
 	if err != nil {
 		return nil, err
 	}
 	return &Share{
 		GithubUserID: share.GithubUserID,
 		Path:         path,
-		EncodedChart: encoded,
+		Chart:        share.Chart,
+		ChartVersion: share.ChartVersion,
+		Timezone:     share.Timezone,
+		WeekStart:    share.WeekStart,
+		Frames:       share.Frames,
 	}, nil
 }
 
@@ -144,15 +229,14 @@ func (s *SharesService) GetAllUserShares(githubUserID uint64) ([]Share, error) {
 
 	res := make([]Share, len(shares))
 	for i, v := range shares {
-		encodedChart, err := chart.Encode(v.Chart)
-		if err != nil {
-			return nil, err
-		}
-
 		res[i] = Share{
 			GithubUserID: v.GithubUserID,
 			Path:         v.Path,
-			EncodedChart: encodedChart,
+			Chart:        v.Chart,
+			ChartVersion: v.ChartVersion,
+			Timezone:     v.Timezone,
+			WeekStart:    v.WeekStart,
+			Frames:       v.Frames,
 		}
 	}
 
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mateusz834/charts/log"
+	"github.com/mateusz834/charts/storage"
+)
+
+// RevocationStorage stores revoked signed sessions.
+type RevocationStorage interface {
+	RevokeSignedSession(r *storage.RevokedSession) error
+	RevokeUserSignedSessions(r *storage.RevokedUser) error
+	GetRevocations() ([]storage.RevokedSession, []storage.RevokedUser, error)
+	RemoveExpiredRevocations(expiredBefore, revokedBefore time.Time) (int64, error)
+}
+
+// SignedSessionOptions are the options of SignedSessionService.
+type SignedSessionOptions struct {
+	SessionOptions
+
+	// Keys are the HMAC-SHA256 keys of sessions, the first one signs new sessions,
+	// all of them are used to verify sessions, so that keys can be rotated.
+	Keys [][]byte
+
+	// Revocations, when not nil, stores the revoked sessions, without it
+	// sessions cannot be revoked (they are valid until they expire).
+	Revocations RevocationStorage
+}
+
+// SignedSessionService is a stateless alternative of SessionService, sessions are HMAC signed
+// tokens, that carry the github user id, so validating them does not need any storage. Revoked
+// sessions (see SignedSessionOptions.Revocations) are kept in memory and reloaded by
+// RemoveExpiredSessions, so revocations made by other servers (using the same storage)
+// are only seen after the next RemoveExpiredSessions call.
+type SignedSessionService struct {
+	options SignedSessionOptions
+
+	mu             sync.RWMutex
+	revokedSession map[revokedSessionKey]revokedSession
+	revokedCount   map[uint64]int       // number of sessions of the user revoked by RevokeSession
+	revokedUser    map[uint64]time.Time // sessions created before are revoked
+}
+
+type revokedSessionKey struct {
+	githubUserID uint64
+	id           int64
+}
+
+type revokedSession struct {
+	expires time.Time // expiry of the revoked session
+	byID    bool      // revoked by RevokeSession (not by logging out)
+}
+
+// MinSignedSessionKeyLength is the minimum length of SignedSessionOptions.Keys.
+const MinSignedSessionKeyLength = 32
+
+// maxRevokedSessionsPerUser limits the revocations made by RevokeSession, the ids are
+// not verified (other sessions are not known), so they could be made up by the user.
+// Sessions revoked by logging out (RemoveSession) are not counted, they are valid.
+const maxRevokedSessionsPerUser = 64
+
+var (
+	errNoSignedSessionKeys      = errors.New("no signed session keys")
+	errSignedSessionKeyTooShort = fmt.Errorf("signed session keys must be at least %v bytes long", MinSignedSessionKeyLength)
+	errRevocationNotSupported   = errors.New("revoking sessions is not enabled")
+	errTooManyRevokedSessions   = errors.New("too many revoked sessions, log out everywhere instead")
+)
+
+// NewSignedSessionService creates the service and loads the revoked sessions.
+func NewSignedSessionService(options SignedSessionOptions) (*SignedSessionService, error) {
+	if len(options.Keys) == 0 {
+		return nil, errNoSignedSessionKeys
+	}
+	for _, v := range options.Keys {
+		if len(v) < MinSignedSessionKeyLength {
+			return nil, errSignedSessionKeyTooShort
+		}
+	}
+
+	s := &SignedSessionService{
+		options:        options,
+		revokedSession: make(map[revokedSessionKey]revokedSession),
+		revokedCount:   make(map[uint64]int),
+		revokedUser:    make(map[uint64]time.Time),
+	}
+
+	if options.Revocations != nil {
+		if err := s.loadRevocations(time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// loadRevocations replaces the revocations in memory with the stored ones. Revocations
+// from memory, that are not expired, are kept, because revocations made concurrently
+// might be missing in the storage result.
+func (s *SignedSessionService) loadRevocations(now time.Time) error {
+	sessions, users, err := s.options.Revocations.GetRevocations()
+	if err != nil {
+		return fmt.Errorf("failed to load revoked sessions: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	oldSessions, oldUsers := s.revokedSession, s.revokedUser
+	s.revokedSession = make(map[revokedSessionKey]revokedSession, len(sessions))
+	s.revokedCount = make(map[uint64]int)
+	s.revokedUser = make(map[uint64]time.Time, len(users))
+
+	for _, v := range sessions {
+		s.addRevokedSession(revokedSessionKey{v.GithubUserID, v.ID}, revokedSession{v.ExpiresAt, v.ByID})
+	}
+	for _, v := range users {
+		s.addRevokedUser(v.GithubUserID, v.RevokedBefore)
+	}
+
+	revokedBefore := now.Add(-s.options.AbsoluteTimeout)
+	for k, v := range oldSessions {
+		if !v.expires.Before(now) {
+			s.addRevokedSession(k, v)
+		}
+	}
+	for k, v := range oldUsers {
+		if !v.Before(revokedBefore) {
+			s.addRevokedUser(k, v)
+		}
+	}
+	return nil
+}
+
+// signedSession is the payload of a signed session.
+type signedSession struct {
+	githubUserID uint64
+
+	// id is a random identifier of the session, it does not change
+	// when the session is renewed. It is at most 2^53-1, so that it
+	// can be represented exactly by JavaScript numbers.
+	id int64
+
+	createdAt  time.Time
+	lastSeenAt time.Time
+}
+
+const (
+	signedSessionVersion    = 1
+	signedSessionPayloadLen = 1 + 8 + 8 + 8 + 8
+	signedSessionLen        = signedSessionPayloadLen + sha256.Size
+	maxSignedSessionID      = 1<<53 - 1
+)
+
+func signSession(key []byte, payload []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+func (s *SignedSessionService) encode(ses *signedSession) string {
+	bin := make([]byte, signedSessionPayloadLen, signedSessionLen)
+	bin[0] = signedSessionVersion
+	binary.BigEndian.PutUint64(bin[1:9], ses.githubUserID)
+	binary.BigEndian.PutUint64(bin[9:17], uint64(ses.id))
+	binary.BigEndian.PutUint64(bin[17:25], uint64(ses.createdAt.UnixNano()))
+	binary.BigEndian.PutUint64(bin[25:33], uint64(ses.lastSeenAt.UnixNano()))
+	bin = append(bin, signSession(s.options.Keys[0], bin)...)
+	return base64.RawURLEncoding.EncodeToString(bin)
+}
+
+func (s *SignedSessionService) decode(session string) (*signedSession, error) {
+	if base64.RawURLEncoding.DecodedLen(len(session)) != signedSessionLen {
+		return nil, PublicWrapperError{errors.New("invalid session length")}
+	}
+
+	bin, err := base64.RawURLEncoding.Strict().DecodeString(session)
+	if err != nil {
+		return nil, PublicWithDebugError{Public: "failed to decode base64-encoded session", Debug: err}
+	}
+
+	payload, mac := bin[:signedSessionPayloadLen], bin[signedSessionPayloadLen:]
+	valid := false
+	for _, key := range s.options.Keys {
+		if hmac.Equal(mac, signSession(key, payload)) {
+			valid = true
+			break
+		}
+	}
+	if !valid || payload[0] != signedSessionVersion {
+		return nil, PublicWrapperError{errors.New("invalid session signature")}
+	}
+
+	return &signedSession{
+		githubUserID: binary.BigEndian.Uint64(payload[1:9]),
+		id:           int64(binary.BigEndian.Uint64(payload[9:17])),
+		createdAt:    time.Unix(0, int64(binary.BigEndian.Uint64(payload[17:25]))),
+		lastSeenAt:   time.Unix(0, int64(binary.BigEndian.Uint64(payload[25:33]))),
+	}, nil
+}
+
+// NewSession creates a session, it returns the session and its expiry. The user agent
+// and the ip are not stored (signed sessions do not use any storage).
+func (s *SignedSessionService) NewSession(githubUserID uint64, userAgent, ip string) (string, time.Time, error) {
+	var rnd [8]byte
+	if _, err := rand.Read(rnd[:]); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate random session id: %v", err)
+	}
+
+	now := time.Now()
+	ses := signedSession{
+		githubUserID: githubUserID,
+		id:           int64(binary.BigEndian.Uint64(rnd[:]) & maxSignedSessionID),
+		createdAt:    now,
+		lastSeenAt:   now,
+	}
+	return s.encode(&ses), s.options.expires(now, now), nil
+}
+
+func (s *SignedSessionService) isRevoked(ses *signedSession) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.revokedSession[revokedSessionKey{ses.githubUserID, ses.id}]; ok {
+		return true
+	}
+	revokedBefore, ok := s.revokedUser[ses.githubUserID]
+	return ok && ses.createdAt.Before(revokedBefore)
+}
+
+// validate decodes the session and checks whether it is not expired nor revoked.
+func (s *SignedSessionService) validate(session string) (*signedSession, error) {
+	ses, err := s.decode(session)
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(s.options.expires(ses.createdAt, ses.lastSeenAt)) {
+		return nil, PublicWrapperError{errSessionExpired}
+	}
+	if s.isRevoked(ses) {
+		return nil, PublicWrapperError{errors.New("session revoked")}
+	}
+	return ses, nil
+}
+
+// IsSessionValid validates the session, sessions that were not used for a while
+// are renewed (a new session is issued), so that they expire IdleTimeout after now.
+func (s *SignedSessionService) IsSessionValid(session string) (ValidSession, error) {
+	ses, err := s.validate(session)
+	if err != nil {
+		return ValidSession{}, err
+	}
+
+	valid := ValidSession{
+		GithubUserID: ses.githubUserID,
+		Session:      session,
+		Expires:      s.options.expires(ses.createdAt, ses.lastSeenAt),
+	}
+
+	if now := time.Now(); now.Sub(ses.lastSeenAt) >= s.options.renewInterval() {
+		ses.lastSeenAt = now
+		valid.Session = s.encode(ses)
+		valid.Expires = s.options.expires(ses.createdAt, now)
+		valid.Renewed = true
+	}
+
+	return valid, nil
+}
+
+// RemoveSession revokes the session, it does nothing when revocations are not enabled,
+// or when the session is not valid (there is nothing to revoke).
+func (s *SignedSessionService) RemoveSession(session string) error {
+	ses, err := s.validate(session)
+	if err != nil {
+		if errors.As(err, new(PublicError)) {
+			return nil
+		}
+		return err
+	}
+	if s.options.Revocations == nil {
+		return nil
+	}
+	return s.revoke(ses.githubUserID, ses.id, revokedSession{expires: ses.createdAt.Add(s.options.AbsoluteTimeout)})
+}
+
+func (s *SignedSessionService) revoke(githubUserID uint64, id int64, v revokedSession) error {
+	r := storage.RevokedSession{GithubUserID: githubUserID, ID: id, ExpiresAt: v.expires, ByID: v.byID}
+	if err := s.options.Revocations.RevokeSignedSession(&r); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.addRevokedSession(revokedSessionKey{githubUserID, id}, v)
+	s.mu.Unlock()
+	return nil
+}
+
+// addRevokedSession adds the revocation to memory, s.mu must be held.
+func (s *SignedSessionService) addRevokedSession(k revokedSessionKey, v revokedSession) {
+	if _, ok := s.revokedSession[k]; !ok && v.byID {
+		s.revokedCount[k.githubUserID]++
+	}
+	s.revokedSession[k] = v
+}
+
+// addRevokedUser adds the revocation of all sessions of the user created before
+// revokedBefore to memory, s.mu must be held.
+func (s *SignedSessionService) addRevokedUser(githubUserID uint64, revokedBefore time.Time) {
+	if revokedBefore.After(s.revokedUser[githubUserID]) {
+		s.revokedUser[githubUserID] = revokedBefore
+	}
+}
+
+// GetUserSessions returns only the passed session, other sessions of the user are not
+// known (they are not stored anywhere), so unlike with SessionService, the sessions on
+// other devices are not listed and cannot be revoked one by one, only all of them at
+// once with RemoveUserSessions.
+func (s *SignedSessionService) GetUserSessions(session string) ([]SessionInfo, error) {
+	ses, err := s.validate(session)
+	if err != nil {
+		return nil, err
+	}
+	return []SessionInfo{{
+		ID:         ses.id,
+		CreatedAt:  ses.createdAt,
+		LastSeenAt: ses.lastSeenAt,
+		Current:    true,
+	}}, nil
+}
+
+// RevokeSession revokes the session of the user with the ID (see SessionInfo.ID),
+// the session stays revoked for AbsoluteTimeout (the maximum lifetime of a session).
+// At most maxRevokedSessionsPerUser sessions of a user can be revoked at once.
+func (s *SignedSessionService) RevokeSession(githubUserID uint64, id int64) error {
+	if s.options.Revocations == nil {
+		return PublicWrapperError{errRevocationNotSupported}
+	}
+
+	s.mu.RLock()
+	_, revoked := s.revokedSession[revokedSessionKey{githubUserID, id}]
+	count := s.revokedCount[githubUserID]
+	s.mu.RUnlock()
+	if revoked {
+		return nil
+	}
+	if count >= maxRevokedSessionsPerUser {
+		return PublicWrapperError{errTooManyRevokedSessions}
+	}
+
+	return s.revoke(githubUserID, id, revokedSession{expires: time.Now().Add(s.options.AbsoluteTimeout), byID: true})
+}
+
+// RemoveUserSessions revokes all sessions of the user created before now.
+func (s *SignedSessionService) RemoveUserSessions(githubUserID uint64) error {
+	if s.options.Revocations == nil {
+		return PublicWrapperError{errRevocationNotSupported}
+	}
+
+	now := time.Now()
+	r := storage.RevokedUser{GithubUserID: githubUserID, RevokedBefore: now}
+	if err := s.options.Revocations.RevokeUserSignedSessions(&r); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.addRevokedUser(githubUserID, now)
+	s.mu.Unlock()
+	return nil
+}
+
+// RemoveExpiredSessions removes revocations of sessions that are expired anyway,
+// and reloads the revocations (so that revocations made by other servers are seen).
+func (s *SignedSessionService) RemoveExpiredSessions() (int64, error) {
+	if s.options.Revocations == nil {
+		return 0, nil
+	}
+
+	now := time.Now()
+	n, err := s.options.Revocations.RemoveExpiredRevocations(now, now.Add(-s.options.AbsoluteTimeout))
+	if err != nil {
+		return 0, err
+	}
+	return n, s.loadRevocations(now)
+}
+
+// CollectExpiredSessions calls RemoveExpiredSessions every interval, it never returns.
+func (s *SignedSessionService) CollectExpiredSessions(interval time.Duration, logger log.Logger) {
+	collectExpired(interval, logger, "expired session revocations", s.RemoveExpiredSessions)
+}
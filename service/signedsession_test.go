@@ -0,0 +1,293 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mateusz834/charts/storage"
+)
+
+var (
+	testKey1 = []byte("0123456789abcdef0123456789abcdef")
+	testKey2 = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func testSignedSessionService(t *testing.T, keys [][]byte, revocations RevocationStorage) *SignedSessionService {
+	s, err := NewSignedSessionService(SignedSessionOptions{
+		SessionOptions: DefaultSessionOptions(),
+		Keys:           keys,
+		Revocations:    revocations,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func testRevocationStorage(t *testing.T) *storage.SqliteStorage {
+	db, err := storage.NewSqliteStorage(filepath.Join(t.TempDir(), "charts.db"), testKey1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &db
+}
+
+func newTestSignedSession(t *testing.T, s *SignedSessionService, githubUserID uint64) string {
+	session, _, err := s.NewSession(githubUserID, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+// isPublicError reports whether the error is a PublicError, errors of invalid sessions
+// are public (shown to the user), so they are not confused with internal errors.
+func isPublicError(err error) bool {
+	return errors.As(err, new(PublicError))
+}
+
+func TestSignedSessionVerification(t *testing.T) {
+	s := testSignedSessionService(t, [][]byte{testKey1}, nil)
+
+	session := newTestSignedSession(t, s, 1000)
+	valid, err := s.IsSessionValid(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid.GithubUserID != 1000 || valid.Session != session || valid.Renewed {
+		t.Errorf("unexpected valid session: %#v", valid)
+	}
+
+	bin, err := base64.RawURLEncoding.DecodeString(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := make([]string, 0, len(bin))
+	for i := range bin {
+		b := append([]byte(nil), bin...)
+		b[i] ^= 1
+		tampered = append(tampered, base64.RawURLEncoding.EncodeToString(b))
+	}
+
+	other := testSignedSessionService(t, [][]byte{testKey2}, nil)
+	invalid := append(tampered, "", "invalid", session[:len(session)-1], session+"A", newTestSignedSession(t, other, 1000))
+	for _, v := range invalid {
+		if _, err := s.IsSessionValid(v); !isPublicError(err) {
+			t.Errorf("IsSessionValid(%q) = %v, want a public error", v, err)
+		}
+	}
+
+	if _, err := NewSignedSessionService(SignedSessionOptions{SessionOptions: DefaultSessionOptions()}); err != errNoSignedSessionKeys {
+		t.Errorf("NewSignedSessionService without keys = %v, want: %v", err, errNoSignedSessionKeys)
+	}
+	_, err = NewSignedSessionService(SignedSessionOptions{
+		SessionOptions: DefaultSessionOptions(),
+		Keys:           [][]byte{testKey1, testKey2[:MinSignedSessionKeyLength-1]},
+	})
+	if err != errSignedSessionKeyTooShort {
+		t.Errorf("NewSignedSessionService with a short key = %v, want: %v", err, errSignedSessionKeyTooShort)
+	}
+}
+
+func TestSignedSessionKeyRotation(t *testing.T) {
+	old := testSignedSessionService(t, [][]byte{testKey1}, nil)
+	rotated := testSignedSessionService(t, [][]byte{testKey2, testKey1}, nil)
+	removed := testSignedSessionService(t, [][]byte{testKey2}, nil)
+
+	oldSession := newTestSignedSession(t, old, 1)
+	if _, err := rotated.IsSessionValid(oldSession); err != nil {
+		t.Errorf("session signed with a previous key is not valid: %v", err)
+	}
+	if _, err := removed.IsSessionValid(oldSession); !isPublicError(err) {
+		t.Errorf("session signed with a removed key = %v, want a public error", err)
+	}
+
+	// New sessions are signed with the first key.
+	newSession := newTestSignedSession(t, rotated, 1)
+	if _, err := removed.IsSessionValid(newSession); err != nil {
+		t.Errorf("session signed after the rotation is not valid with the new key: %v", err)
+	}
+	if _, err := old.IsSessionValid(newSession); !isPublicError(err) {
+		t.Errorf("session signed with the new key is valid with the old key only: %v", err)
+	}
+}
+
+func TestSignedSessionExpiry(t *testing.T) {
+	s := testSignedSessionService(t, [][]byte{testKey1}, nil)
+	day := 24 * time.Hour
+	now := time.Now()
+
+	tests := []struct {
+		name              string
+		created, lastSeen time.Duration
+		valid             bool
+	}{
+		{"fresh", 0, 0, true},
+		{"used recently", 29 * day, 6 * day, true},
+		{"absolute", 31 * day, time.Hour, false},
+		{"idle", 10 * day, 8 * day, false},
+	}
+
+	for _, tt := range tests {
+		session := s.encode(&signedSession{
+			githubUserID: 1,
+			id:           1,
+			createdAt:    now.Add(-tt.created),
+			lastSeenAt:   now.Add(-tt.lastSeen),
+		})
+		_, err := s.IsSessionValid(session)
+		if tt.valid && err != nil {
+			t.Errorf("%v: unexpected error: %v", tt.name, err)
+		}
+		if !tt.valid && err != (PublicWrapperError{errSessionExpired}) {
+			t.Errorf("%v: IsSessionValid() = %v, want: %v", tt.name, err, errSessionExpired)
+		}
+	}
+}
+
+func TestSignedSessionRenewal(t *testing.T) {
+	s := testSignedSessionService(t, [][]byte{testKey2, testKey1}, nil)
+	old := testSignedSessionService(t, [][]byte{testKey1}, nil)
+
+	now := time.Now()
+	ses := signedSession{
+		githubUserID: 1,
+		id:           123,
+		createdAt:    now.Add(-48 * time.Hour),
+		lastSeenAt:   now.Add(-2 * time.Hour),
+	}
+	session := old.encode(&ses)
+
+	valid, err := s.IsSessionValid(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !valid.Renewed || valid.Session == session {
+		t.Fatalf("session not renewed: %#v", valid)
+	}
+	if d := valid.Expires.Sub(now.Add(s.options.IdleTimeout)); d < 0 || d > time.Minute {
+		t.Errorf("renewed session expires at %v, want: IdleTimeout after now", valid.Expires)
+	}
+
+	// The renewed session is signed with the first key and keeps the id and the creation time.
+	renewed, err := testSignedSessionService(t, [][]byte{testKey2}, nil).decode(valid.Session)
+	if err != nil {
+		t.Fatalf("renewed session is not signed with the first key: %v", err)
+	}
+	if renewed.githubUserID != ses.githubUserID || renewed.id != ses.id || !renewed.createdAt.Equal(ses.createdAt) ||
+		!renewed.lastSeenAt.After(ses.lastSeenAt) {
+		t.Errorf("unexpected renewed session: %#v, before renewal: %#v", renewed, ses)
+	}
+
+	// Sessions used recently are not renewed.
+	valid, err = s.IsSessionValid(valid.Session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid.Renewed {
+		t.Errorf("recently renewed session renewed again: %#v", valid)
+	}
+}
+
+func TestSignedSessionRevocation(t *testing.T) {
+	db := testRevocationStorage(t)
+	s := testSignedSessionService(t, [][]byte{testKey1}, db)
+	other := testSignedSessionService(t, [][]byte{testKey1}, db)
+
+	session := newTestSignedSession(t, s, 1)
+	if err := s.RemoveSession(session); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.IsSessionValid(session); !isPublicError(err) {
+		t.Errorf("IsSessionValid of a logged out session = %v, want a public error", err)
+	}
+
+	// Revocations made by other servers are seen after reloading them.
+	if _, err := other.IsSessionValid(session); err != nil {
+		t.Errorf("logged out session invalid before reloading the revocations: %v", err)
+	}
+	if _, err := other.RemoveExpiredSessions(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.IsSessionValid(session); !isPublicError(err) {
+		t.Errorf("IsSessionValid of a logged out session after reload = %v, want a public error", err)
+	}
+
+	// Logging out does not count towards maxRevokedSessionsPerUser.
+	for i := 0; i < maxRevokedSessionsPerUser; i++ {
+		if err := s.RemoveSession(newTestSignedSession(t, s, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	session = newTestSignedSession(t, s, 1)
+	sessions, err := s.GetUserSessions(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || !sessions[0].Current {
+		t.Fatalf("unexpected sessions: %#v", sessions)
+	}
+	if err := s.RevokeSession(1, sessions[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.IsSessionValid(session); !isPublicError(err) {
+		t.Errorf("IsSessionValid of a revoked session = %v, want a public error", err)
+	}
+	if err := s.RevokeSession(1, sessions[0].ID); err != nil {
+		t.Errorf("revoking a revoked session again = %v", err)
+	}
+
+	for id := int64(1); id < maxRevokedSessionsPerUser; id++ {
+		if err := s.RevokeSession(1, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.RevokeSession(1, maxRevokedSessionsPerUser); err != (PublicWrapperError{errTooManyRevokedSessions}) {
+		t.Errorf("RevokeSession over the limit = %v, want: %v", err, errTooManyRevokedSessions)
+	}
+	if err := s.RevokeSession(2, 1); err != nil {
+		t.Errorf("RevokeSession of another user = %v", err)
+	}
+
+	// The revocations and the limit are kept after a restart.
+	restarted := testSignedSessionService(t, [][]byte{testKey1}, db)
+	if _, err := restarted.IsSessionValid(session); !isPublicError(err) {
+		t.Errorf("IsSessionValid of a revoked session after a restart = %v, want a public error", err)
+	}
+	if err := restarted.RevokeSession(1, maxRevokedSessionsPerUser); err != (PublicWrapperError{errTooManyRevokedSessions}) {
+		t.Errorf("RevokeSession over the limit after a restart = %v, want: %v", err, errTooManyRevokedSessions)
+	}
+
+	session = newTestSignedSession(t, s, 3)
+	if err := s.RemoveUserSessions(3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.IsSessionValid(session); !isPublicError(err) {
+		t.Errorf("IsSessionValid after logging out everywhere = %v, want a public error", err)
+	}
+	if _, err := s.IsSessionValid(newTestSignedSession(t, s, 3)); err != nil {
+		t.Errorf("session created after logging out everywhere is not valid: %v", err)
+	}
+}
+
+func TestSignedSessionWithoutRevocations(t *testing.T) {
+	s := testSignedSessionService(t, [][]byte{testKey1}, nil)
+
+	session := newTestSignedSession(t, s, 1)
+	if err := s.RemoveSession(session); err != nil {
+		t.Errorf("RemoveSession = %v, want: nil", err)
+	}
+	if _, err := s.IsSessionValid(session); err != nil {
+		t.Errorf("session is not valid after logging out without revocations: %v", err)
+	}
+	if err := s.RevokeSession(1, 1); err != (PublicWrapperError{errRevocationNotSupported}) {
+		t.Errorf("RevokeSession = %v, want: %v", err, errRevocationNotSupported)
+	}
+	if err := s.RemoveUserSessions(1); err != (PublicWrapperError{errRevocationNotSupported}) {
+		t.Errorf("RemoveUserSessions = %v, want: %v", err, errRevocationNotSupported)
+	}
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Migration is a single step of the database schema, it upgrades
+// the schema from Version-1 to Version.
+type Migration struct {
+	Version     int
+	Description string
+
+	up func(tx *sql.Tx) error
+}
+
+// migrations are all schema migrations, in order, migrations[i] has version i+1.
+// The schema version of a database is stored in PRAGMA user_version, databases
+// created before migrations were introduced have version 0.
+//
+// Never change an existing migration, append a new one instead.
+var migrations = []Migration{
+	{
+		Description: "create sessions and shares tables",
+		up: execMigration(`
+CREATE TABLE IF NOT EXISTS sessions (
+	github_user_id INTEGER NOT NULL,
+	session_id BLOB NOT NULL,
+	created_at INTEGER NOT NULL
+) STRICT;
+
+CREATE TABLE IF NOT EXISTS shares (
+	github_user_id INTEGER NOT NULL,
+	path TEXT NOT NULL,
+	chart BLOB NOT NULL,
+	created_at INTEGER NOT NULL
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS shares_unique_path ON shares (path);
+`),
+	},
+	{
+		// Shares created before chart versions were recorded, were always version '0'.
+		Description: "add shares.chart_version",
+		up:          execMigration(`ALTER TABLE shares ADD COLUMN chart_version TEXT NOT NULL DEFAULT '0';`),
+	},
+	{
+		// Shares created before timezones were recorded, have an empty (unknown) timezone.
+		Description: "add shares.timezone",
+		up:          execMigration(`ALTER TABLE shares ADD COLUMN timezone TEXT NOT NULL DEFAULT '';`),
+	},
+	{
+		// Shares created before week starts were recorded, were drawn for weeks starting on sunday.
+		Description: "add shares.week_start",
+		up:          execMigration(`ALTER TABLE shares ADD COLUMN week_start INTEGER NOT NULL DEFAULT 0;`),
+	},
+	{
+		// Shares created before frames were supported, do not have any frames.
+		Description: "add shares.frames",
+		up:          execMigration(`ALTER TABLE shares ADD COLUMN frames TEXT NOT NULL DEFAULT '';`),
+	},
+	{
+		// Existing sessions were last seen when they were created. The table is recreated,
+		// because a primary key cannot be added with ALTER TABLE.
+		Description: "add sessions.id and sessions.last_seen_at",
+		up: execMigration(`
+CREATE TABLE sessions_new (
+	id INTEGER PRIMARY KEY,
+	github_user_id INTEGER NOT NULL,
+	session_id BLOB NOT NULL,
+	created_at INTEGER NOT NULL,
+	last_seen_at INTEGER NOT NULL
+) STRICT;
+
+INSERT INTO sessions_new (github_user_id, session_id, created_at, last_seen_at)
+	SELECT github_user_id, session_id, created_at, created_at FROM sessions;
+
+DROP TABLE sessions;
+ALTER TABLE sessions_new RENAME TO sessions;
+`),
+	},
+	{
+		// The user agent and ip of existing sessions are unknown.
+		Description: "add sessions.user_agent and sessions.ip",
+		up: execMigration(`
+ALTER TABLE sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN ip TEXT NOT NULL DEFAULT '';
+CREATE INDEX sessions_github_user_id ON sessions (github_user_id);
+`),
+	},
+	{
+		// Session ids were stored in plain text, they cannot be converted to
+		// keyed hashes here (the key is not known to migrations), so all existing
+		// sessions are invalidated (users have to login again).
+		Description: "store keyed hashes of session ids",
+		up: execMigration(`
+DELETE FROM sessions;
+ALTER TABLE sessions RENAME COLUMN session_id TO session_hash;
+`),
+	},
+	{
+		Description: "create revocation tables of signed sessions",
+		up: execMigration(`
+CREATE TABLE revoked_signed_sessions (
+	github_user_id INTEGER NOT NULL,
+	session_id INTEGER NOT NULL,
+	expires_at_ns INTEGER NOT NULL,
+	by_id INTEGER NOT NULL
+) STRICT;
+
+CREATE TABLE revoked_signed_users (
+	github_user_id INTEGER PRIMARY KEY,
+	revoked_before_ns INTEGER NOT NULL
+) STRICT;
+`),
+	},
+}
+
+func init() {
+	for i := range migrations {
+		migrations[i].Version = i + 1
+	}
+}
+
+// LatestSchemaVersion is the schema version after applying all migrations.
+func LatestSchemaVersion() int {
+	return len(migrations)
+}
+
+var ErrSchemaTooNew = errors.New("database schema is newer than supported by this version")
+
+func execMigration(query string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func schemaVersion(db queryRower) (int, error) {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, err
+	}
+	if version > len(migrations) {
+		return 0, fmt.Errorf("%w: database version %v, latest known version %v", ErrSchemaTooNew, version, len(migrations))
+	}
+	return version, nil
+}
+
+// pendingMigrations returns the migrations that are not yet applied to the database.
+func pendingMigrations(db *sql.DB) ([]Migration, error) {
+	version, err := schemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	return migrations[version:], nil
+}
+
+// migrate applies all pending migrations, every one in a separate transaction.
+func migrate(db *sql.DB) ([]Migration, error) {
+	pending, err := pendingMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, m := range pending {
+		if err := applyMigration(db, m); err != nil {
+			return pending[:i], fmt.Errorf("migration %v (%v) failed: %v", m.Version, m.Description, err)
+		}
+	}
+	return pending, nil
+}
+
+func applyMigration(db *sql.DB, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Another process might have migrated the database in the meantime.
+	version, err := schemaVersion(tx)
+	if err != nil {
+		return err
+	}
+	if version >= m.Version {
+		return nil
+	}
+	if version != m.Version-1 {
+		return fmt.Errorf("unexpected schema version %v", version)
+	}
+
+	if err := m.up(tx); err != nil {
+		return err
+	}
+
+	// PRAGMA does not support bound parameters.
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", m.Version)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SqliteSchemaStatus opens the database and returns its schema
+// version and the pending migrations, without applying them.
+func SqliteSchemaStatus(path string) (version int, pending []Migration, err error) {
+	db, err := openSqlite(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer db.Close()
+
+	pending, err = pendingMigrations(db)
+	if err != nil {
+		return 0, nil, err
+	}
+	return LatestSchemaVersion() - len(pending), pending, nil
+}
+
+// MigrateSqlite opens the database and applies all pending migrations,
+// it returns the applied migrations.
+func MigrateSqlite(path string) ([]Migration, error) {
+	db, err := openSqlite(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return migrate(db)
+}
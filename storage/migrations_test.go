@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mateusz834/charts/chart"
+)
+
+// baselineSchema is the schema created by the versions before migrations were introduced.
+const baselineSchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	github_user_id INTEGER NOT NULL,
+	session_id BLOB NOT NULL,
+	created_at INTEGER NOT NULL
+) STRICT;
+
+CREATE TABLE IF NOT EXISTS shares (
+	github_user_id INTEGER NOT NULL,
+	path TEXT NOT NULL,
+	chart BLOB NOT NULL,
+	created_at INTEGER NOT NULL
+) STRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS shares_unique_path ON shares (path);
+`
+
+func testDB(t *testing.T) (path string, db *sql.DB) {
+	path = filepath.Join(t.TempDir(), "charts.db")
+	db, err := openSqlite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return path, db
+}
+
+func testSchemaVersion(t *testing.T, path string) int {
+	version, pending, err := SqliteSchemaStatus(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("unexpected pending migrations after migrating: %v", pending)
+	}
+	return version
+}
+
+func TestMigrateFresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "charts.db")
+
+	applied, err := MigrateSqlite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != LatestSchemaVersion() {
+		t.Errorf("applied %v migrations, want: %v", len(applied), LatestSchemaVersion())
+	}
+	if version := testSchemaVersion(t, path); version != LatestSchemaVersion() {
+		t.Errorf("schema version %v, want: %v", version, LatestSchemaVersion())
+	}
+
+	applied, err = MigrateSqlite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("re-running applied migrations: %v", applied)
+	}
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	path, db := testDB(t)
+	if _, err := db.Exec(baselineSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := chart.New(2023)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	rawChart, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO shares VALUES(1, 'path', ?, UNIXEPOCH())", rawChart); err != nil {
+		t.Fatal(err)
+	}
+	session := Session{GithubUserID: 1, SessionID: [32]byte{1}}
+	if _, err := db.Exec("INSERT INTO sessions VALUES(1, ?, 1000)", session.SessionID[:]); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	s, err := NewSqliteStorage(path, testSessionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.sql.Close()
+
+	if version := testSchemaVersion(t, path); version != LatestSchemaVersion() {
+		t.Errorf("schema version %v, want: %v", version, LatestSchemaVersion())
+	}
+
+	share, err := s.GetShare("path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if share.GithubUserID != 1 || !share.Chart.Equal(c) || share.ChartVersion != chart.VersionBits ||
+		share.Timezone != "" || share.WeekStart != time.Sunday || share.Frames != nil {
+		t.Errorf("unexpected share after migration: %#v", share)
+	}
+
+	// Sessions are removed by the migration to hashed session ids.
+	if err := s.GetSession(&session); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetSession of a session created before the migration = %v, want: %v", err, ErrNotFound)
+	}
+}
+
+func TestMigrateTooNew(t *testing.T) {
+	path, db := testDB(t)
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", LatestSchemaVersion()+1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewSqliteStorage(path, testSessionKey); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("NewSqliteStorage() = %v, want: %v", err, ErrSchemaTooNew)
+	}
+	if _, _, err := SqliteSchemaStatus(path); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("SqliteSchemaStatus() = %v, want: %v", err, ErrSchemaTooNew)
+	}
+}
@@ -0,0 +1,107 @@
+package storage
+
+import "time"
+
+// RevokedSession is a revoked signed session (see service.SignedSessionService),
+// it stays revoked until ExpiresAt, then the session is expired anyway.
+type RevokedSession struct {
+	GithubUserID uint64
+	ID           int64
+	ExpiresAt    time.Time
+
+	// ByID reports that the session was revoked by its ID (from the list of
+	// sessions), rather than by logging out of the session.
+	ByID bool
+}
+
+// RevokedUser revokes all signed sessions of the user created before RevokedBefore.
+type RevokedUser struct {
+	GithubUserID  uint64
+	RevokedBefore time.Time
+}
+
+func (d *SqliteStorage) RevokeSignedSession(r *RevokedSession) error {
+	_, err := d.sql.Exec(
+		"INSERT INTO revoked_signed_sessions (github_user_id, session_id, expires_at_ns, by_id) VALUES(?, ?, ?, ?)",
+		r.GithubUserID, r.ID, r.ExpiresAt.UnixNano(), r.ByID,
+	)
+	return err
+}
+
+func (d *SqliteStorage) RevokeUserSignedSessions(r *RevokedUser) error {
+	_, err := d.sql.Exec(
+		`INSERT INTO revoked_signed_users (github_user_id, revoked_before_ns) VALUES(?, ?)
+		ON CONFLICT (github_user_id) DO UPDATE SET revoked_before_ns = MAX(revoked_before_ns, excluded.revoked_before_ns)`,
+		r.GithubUserID, r.RevokedBefore.UnixNano(),
+	)
+	return err
+}
+
+// GetRevocations returns all revocations of signed sessions.
+func (d *SqliteStorage) GetRevocations() ([]RevokedSession, []RevokedUser, error) {
+	res, err := d.sql.Query("SELECT github_user_id, session_id, expires_at_ns, by_id FROM revoked_signed_sessions")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Close()
+
+	var sessions []RevokedSession
+	for res.Next() {
+		var r RevokedSession
+		var expiresAt int64
+		if err := res.Scan(&r.GithubUserID, &r.ID, &expiresAt, &r.ByID); err != nil {
+			return nil, nil, err
+		}
+		r.ExpiresAt = time.Unix(0, expiresAt)
+		sessions = append(sessions, r)
+	}
+	if err := res.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	res, err = d.sql.Query("SELECT github_user_id, revoked_before_ns FROM revoked_signed_users")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Close()
+
+	var users []RevokedUser
+	for res.Next() {
+		var r RevokedUser
+		var revokedBefore int64
+		if err := res.Scan(&r.GithubUserID, &revokedBefore); err != nil {
+			return nil, nil, err
+		}
+		r.RevokedBefore = time.Unix(0, revokedBefore)
+		users = append(users, r)
+	}
+	if err := res.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return sessions, users, nil
+}
+
+// RemoveExpiredRevocations removes revoked sessions that expired before expiredBefore
+// and revoked users revoked before revokedBefore, it returns the number of removed revocations.
+func (d *SqliteStorage) RemoveExpiredRevocations(expiredBefore, revokedBefore time.Time) (int64, error) {
+	res, err := d.sql.Exec("DELETE FROM revoked_signed_sessions WHERE expires_at_ns < ?", expiredBefore.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	sessions, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err = d.sql.Exec("DELETE FROM revoked_signed_users WHERE revoked_before_ns < ?", revokedBefore.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	users, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return sessions + users, nil
+}
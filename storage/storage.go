@@ -1,79 +1,230 @@
 package storage
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"database/sql"
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/mateusz834/charts/chart"
 	"github.com/mattn/go-sqlite3"
 )
 
 var ErrNotFound = errors.New("not found requested data")
 
-const createTables = `
-CREATE TABLE IF NOT EXISTS sessions (
-	github_user_id INTEGER NOT NULL,
-	session_id BLOB NOT NULL,
-	created_at INTEGER NOT NULL
-) STRICT;
-
-CREATE TABLE IF NOT EXISTS shares (
-	github_user_id INTEGER NOT NULL,
-	path TEXT NOT NULL,
-	chart BLOB NOT NULL,
-	created_at INTEGER NOT NULL
-) STRICT;
-
-CREATE UNIQUE INDEX IF NOT EXISTS shares_unique_path ON shares (path);
-`
-
 type SqliteStorage struct {
 	sql *sql.DB
+
+	// sessionKey is the HMAC-SHA256 key of session ids, only
+	// the keyed hashes of session ids are stored in the database.
+	sessionKey []byte
+}
+
+func openSqlite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed while oppening sqlite database: %v: %v", path, err)
+	}
+	return db, nil
 }
 
-func NewSqliteStorage(path string) (SqliteStorage, error) {
-	sql, err := sql.Open("sqlite3", path)
+// MinSessionKeyLength is the minimum length of the session key of NewSqliteStorage.
+const MinSessionKeyLength = 32
+
+var errSessionKeyTooShort = fmt.Errorf("session key must be at least %v bytes long", MinSessionKeyLength)
+
+// NewSqliteStorage opens the database and migrates its schema to the latest version,
+// it fails with ErrSchemaTooNew when the database was migrated by a newer version.
+// sessionKey is the secret key of the hashes of session ids (see MinSessionKeyLength).
+func NewSqliteStorage(path string, sessionKey []byte) (SqliteStorage, error) {
+	if len(sessionKey) < MinSessionKeyLength {
+		return SqliteStorage{}, errSessionKeyTooShort
+	}
+
+	sql, err := openSqlite(path)
 	if err != nil {
-		return SqliteStorage{}, fmt.Errorf("failed while oppening sqlite database: %v: %v", path, err)
+		return SqliteStorage{}, err
 	}
 
-	if _, err := sql.Exec(createTables); err != nil {
-		return SqliteStorage{}, fmt.Errorf("failed while creating default schema: %v", err)
+	if _, err := migrate(sql); err != nil {
+		sql.Close()
+		return SqliteStorage{}, fmt.Errorf("failed while migrating schema: %w", err)
 	}
 
 	return SqliteStorage{
-		sql: sql,
+		sql:        sql,
+		sessionKey: sessionKey,
 	}, nil
 }
 
 type Session struct {
 	GithubUserID uint64
-	SessionID    [32]byte
+
+	// SessionID is the secret of the session, it is not
+	// stored in the database, only its keyed hash is.
+	SessionID [32]byte
+
+	// UserAgent and IP of the client that created the session.
+	UserAgent string
+	IP        string
+
+	// ID identifies the session among sessions of the user without revealing the
+	// SessionID. ID, CreatedAt and LastSeenAt are set by GetSession and GetUserSessions.
+	ID         int64
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+}
+
+// sessionHash returns the keyed hash of the session id.
+func (d *SqliteStorage) sessionHash(s *Session) []byte {
+	h := hmac.New(sha256.New, d.sessionKey)
+	h.Write(s.SessionID[:])
+	return h.Sum(nil)
 }
 
 func (d *SqliteStorage) StoreSession(s *Session) error {
-	_, err := d.sql.Exec("INSERT INTO sessions VALUES(?, ?, UNIXEPOCH())", s.GithubUserID, s.SessionID[:])
+	_, err := d.sql.Exec(
+		"INSERT INTO sessions (github_user_id, session_hash, created_at, last_seen_at, user_agent, ip) VALUES(?, ?, UNIXEPOCH(), UNIXEPOCH(), ?, ?)",
+		s.GithubUserID, d.sessionHash(s), s.UserAgent, s.IP,
+	)
 	return err
 }
 
-func (d *SqliteStorage) IsSessionValid(s *Session) error {
-	res, err := d.sql.Query("SELECT * FROM sessions WHERE github_user_id = ? AND session_id = ?", s.GithubUserID, s.SessionID[:])
+// GetSession sets the ID, CreatedAt and LastSeenAt fields of the session,
+// it returns ErrNotFound when the session does not exist.
+func (d *SqliteStorage) GetSession(s *Session) error {
+	// Hashes are compared in constant time (instead of being looked
+	// up by the database), between all sessions of the user.
+	res, err := d.sql.Query(
+		"SELECT id, session_hash, created_at, last_seen_at FROM sessions WHERE github_user_id = ?",
+		s.GithubUserID,
+	)
 	if err != nil {
 		return err
 	}
 	defer res.Close()
-	if !res.Next() {
+
+	hash := d.sessionHash(s)
+	found := false
+	for res.Next() {
+		var (
+			id                    int64
+			storedHash            []byte
+			createdAt, lastSeenAt int64
+		)
+		if err := res.Scan(&id, &storedHash, &createdAt, &lastSeenAt); err != nil {
+			return err
+		}
+		if hmac.Equal(hash, storedHash) {
+			s.ID = id
+			s.CreatedAt = time.Unix(createdAt, 0)
+			s.LastSeenAt = time.Unix(lastSeenAt, 0)
+			found = true
+		}
+	}
+
+	if err := res.Err(); err != nil {
+		return err
+	}
+	if !found {
 		return ErrNotFound
 	}
 	return nil
 }
 
+// TouchSession sets the last seen time of the session to now,
+// s must be returned by GetSession (it uses the ID).
+func (d *SqliteStorage) TouchSession(s *Session) error {
+	_, err := d.sql.Exec(
+		"UPDATE sessions SET last_seen_at = UNIXEPOCH() WHERE github_user_id = ? AND id = ?",
+		s.GithubUserID, s.ID,
+	)
+	return err
+}
+
+// RemoveSession removes the session, it does nothing when the session does not exist.
 func (d *SqliteStorage) RemoveSession(s *Session) error {
-	_, err := d.sql.Exec("DELETE FROM sessions WHERE github_user_id = ? AND session_id = ?", s.GithubUserID, s.SessionID[:])
+	if err := d.GetSession(s); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := d.RemoveSessionByID(s.GithubUserID, s.ID); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// GetUserSessions returns all sessions of the user (without SessionIDs),
+// ordered by the last seen time, the most recent first.
+func (d *SqliteStorage) GetUserSessions(githubUserID uint64) ([]Session, error) {
+	res, err := d.sql.Query(
+		"SELECT id, created_at, last_seen_at, user_agent, ip FROM sessions WHERE github_user_id = ? ORDER BY last_seen_at DESC, id DESC",
+		githubUserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	var sessions []Session
+	for res.Next() {
+		s := Session{GithubUserID: githubUserID}
+		var createdAt, lastSeenAt int64
+		if err := res.Scan(&s.ID, &createdAt, &lastSeenAt, &s.UserAgent, &s.IP); err != nil {
+			return nil, err
+		}
+		s.CreatedAt = time.Unix(createdAt, 0)
+		s.LastSeenAt = time.Unix(lastSeenAt, 0)
+		sessions = append(sessions, s)
+	}
+
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RemoveSessionByID removes the session of the user with the ID (see Session.ID),
+// it returns ErrNotFound when the user does not have such session.
+func (d *SqliteStorage) RemoveSessionByID(githubUserID uint64, id int64) error {
+	res, err := d.sql.Exec("DELETE FROM sessions WHERE github_user_id = ? AND id = ?", githubUserID, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RemoveUserSessions removes all sessions of the user.
+func (d *SqliteStorage) RemoveUserSessions(githubUserID uint64) error {
+	_, err := d.sql.Exec("DELETE FROM sessions WHERE github_user_id = ?", githubUserID)
 	return err
 }
 
+// RemoveExpiredSessions removes sessions created before createdBefore or last seen
+// before lastSeenBefore, it returns the number of removed sessions.
+func (d *SqliteStorage) RemoveExpiredSessions(createdBefore, lastSeenBefore time.Time) (int64, error) {
+	res, err := d.sql.Exec(
+		"DELETE FROM sessions WHERE created_at < ? OR last_seen_at < ?",
+		createdBefore.Unix(), lastSeenBefore.Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (d *SqliteStorage) IsPathAvail(path string) (bool, error) {
 	res, err := d.sql.Query("SELECT * FROM shares WHERE path = ?", path)
 	if err != nil {
@@ -86,7 +237,18 @@ func (d *SqliteStorage) IsPathAvail(path string) (bool, error) {
 type Share struct {
 	GithubUserID uint64
 	Path         string
-	Chart        []byte
+	Chart        *chart.Chart
+	ChartVersion chart.Version
+
+	// Timezone is the IANA timezone name or UTC offset of the share
+	// creator, empty when unknown.
+	Timezone string
+
+	// WeekStart is the first weekday of the calendar that the chart was drawn for.
+	WeekStart time.Weekday
+
+	// Frames are the animation frames shown after Chart.
+	Frames []*chart.Chart
 }
 
 var createShareMutex sync.Mutex
@@ -108,7 +270,23 @@ func (d *SqliteStorage) CreateShare(share *Share, maxSharesPerUser int) (bool, e
 		return false, ErrTooMuchShares
 	}
 
-	_, err := d.sql.Exec("INSERT INTO shares VALUES(?, ?, ?, UNIXEPOCH())", share.GithubUserID, share.Path, share.Chart)
+	rawChart, err := share.Chart.MarshalBinary()
+	if err != nil {
+		return false, err
+	}
+
+	var frames string
+	if len(share.Frames) != 0 {
+		frames, err = chart.EncodeFrames(share.Frames)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	_, err = d.sql.Exec(
+		"INSERT INTO shares (github_user_id, path, chart, created_at, chart_version, timezone, week_start, frames) VALUES(?, ?, ?, UNIXEPOCH(), ?, ?, ?, ?)",
+		share.GithubUserID, share.Path, rawChart, share.ChartVersion.String(), share.Timezone, int(share.WeekStart), frames,
+	)
 	if err != nil {
 		var sqliteErr sqlite3.Error
 		if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrConstraint && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
@@ -120,32 +298,73 @@ func (d *SqliteStorage) CreateShare(share *Share, maxSharesPerUser int) (bool, e
 }
 
 func (d *SqliteStorage) GetShare(path string) (*Share, error) {
-	row := d.sql.QueryRow("SELECT github_user_id, chart FROM shares WHERE path = ?", path)
+	row := d.sql.QueryRow("SELECT github_user_id, chart, chart_version, timezone, week_start, frames FROM shares WHERE path = ?", path)
 
-	ret := &Share{Path: path}
-	if err := row.Scan(&ret.GithubUserID, &ret.Chart); err != nil {
+	ret := &Share{Path: path, Chart: new(chart.Chart)}
+	var rawChart []byte
+	var version string
+	var frames string
+	if err := row.Scan(&ret.GithubUserID, &rawChart, &version, &ret.Timezone, &ret.WeekStart, &frames); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
 		}
 		return nil, err
 	}
 
+	if err := ret.Chart.UnmarshalBinary(rawChart); err != nil {
+		return nil, fmt.Errorf("failed to decode chart of share %q: %v", path, err)
+	}
+	v, err := parseChartVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode chart of share %q: %v", path, err)
+	}
+	ret.ChartVersion = v
+	if ret.Frames, err = decodeFrames(frames); err != nil {
+		return nil, fmt.Errorf("failed to decode frames of share %q: %v", path, err)
+	}
+
 	return ret, nil
 }
 
+func decodeFrames(frames string) ([]*chart.Chart, error) {
+	if frames == "" {
+		return nil, nil
+	}
+	return chart.DecodeFrames(frames)
+}
+
+func parseChartVersion(version string) (chart.Version, error) {
+	if len(version) != 1 {
+		return 0, fmt.Errorf("invalid chart version: %q", version)
+	}
+	return chart.Version(version[0]), nil
+}
+
 func (d *SqliteStorage) GetUserShares(githubUserID uint64) ([]Share, error) {
-	res, err := d.sql.Query("SELECT path, chart FROM shares WHERE github_user_id = ?", githubUserID)
+	res, err := d.sql.Query("SELECT path, chart, chart_version, timezone, week_start, frames FROM shares WHERE github_user_id = ?", githubUserID)
 	if err != nil {
 		return nil, err
 	}
 
 	shares := make([]Share, 0, 8)
 	for res.Next() {
-		share := Share{GithubUserID: githubUserID}
+		share := Share{GithubUserID: githubUserID, Chart: new(chart.Chart)}
+		var rawChart []byte
+		var version, frames string
 		// TODO: is this required for correct error handling, doesn't the Err() method below hadle that too.??
-		if err := res.Scan(&share.Path, &share.Chart); err != nil {
+		if err := res.Scan(&share.Path, &rawChart, &version, &share.Timezone, &share.WeekStart, &frames); err != nil {
 			return nil, err
 		}
+		if err := share.Chart.UnmarshalBinary(rawChart); err != nil {
+			return nil, fmt.Errorf("failed to decode chart of share %q: %v", share.Path, err)
+		}
+		share.ChartVersion, err = parseChartVersion(version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode chart of share %q: %v", share.Path, err)
+		}
+		if share.Frames, err = decodeFrames(frames); err != nil {
+			return nil, fmt.Errorf("failed to decode frames of share %q: %v", share.Path, err)
+		}
 		shares = append(shares, share)
 	}
 
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testSessionKey = []byte("0123456789abcdef0123456789abcdef")
+
+func testStorage(t *testing.T) *SqliteStorage {
+	s, err := NewSqliteStorage(filepath.Join(t.TempDir(), "charts.db"), testSessionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.sql.Close() })
+	return &s
+}
+
+// backdateSession moves the creation and last seen times of the session back in time.
+func backdateSession(t *testing.T, d *SqliteStorage, s *Session, created, lastSeen time.Duration) {
+	_, err := d.sql.Exec(
+		"UPDATE sessions SET created_at = created_at - ?, last_seen_at = last_seen_at - ? WHERE github_user_id = ? AND session_hash = ?",
+		int64(created/time.Second), int64(lastSeen/time.Second), s.GithubUserID, d.sessionHash(s),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSessionHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "charts.db")
+	d, err := NewSqliteStorage(path, testSessionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.sql.Close()
+
+	s := Session{GithubUserID: 1, SessionID: [32]byte{1, 2, 3}}
+	if err := d.StoreSession(&s); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored []byte
+	if err := d.sql.QueryRow("SELECT session_hash FROM sessions").Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, d.sessionHash(&s)) || bytes.Contains(stored, s.SessionID[:3]) {
+		t.Errorf("unexpected stored session hash: %x", stored)
+	}
+
+	found := Session{GithubUserID: 1, SessionID: s.SessionID}
+	if err := d.GetSession(&found); err != nil {
+		t.Fatal(err)
+	}
+	if found.ID == 0 || found.CreatedAt.IsZero() {
+		t.Errorf("GetSession did not set the session fields: %#v", found)
+	}
+
+	for _, v := range []Session{
+		{GithubUserID: 1, SessionID: [32]byte{1, 2, 4}},
+		{GithubUserID: 2, SessionID: s.SessionID},
+	} {
+		if err := d.GetSession(&v); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetSession(%v, %x) = %v, want: %v", v.GithubUserID, v.SessionID[:3], err, ErrNotFound)
+		}
+	}
+
+	// The hashes are keyed, so with a different key the session is not found.
+	otherKey, err := NewSqliteStorage(path, []byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer otherKey.sql.Close()
+	if err := otherKey.GetSession(&Session{GithubUserID: 1, SessionID: s.SessionID}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetSession with a different key = %v, want: %v", err, ErrNotFound)
+	}
+
+	if _, err := NewSqliteStorage(path, testSessionKey[:MinSessionKeyLength-1]); err != errSessionKeyTooShort {
+		t.Errorf("NewSqliteStorage with a short key = %v, want: %v", err, errSessionKeyTooShort)
+	}
+}
+
+func TestSessionRenewal(t *testing.T) {
+	d := testStorage(t)
+
+	s := Session{GithubUserID: 1, SessionID: [32]byte{1}}
+	if err := d.StoreSession(&s); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []Session{{GithubUserID: 1, SessionID: [32]byte{2}}, {GithubUserID: 2, SessionID: [32]byte{1}}} {
+		if err := d.GetSession(&v); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetSession(%v, %x) = %v, want: %v", v.GithubUserID, v.SessionID[:1], err, ErrNotFound)
+		}
+	}
+
+	backdateSession(t, d, &s, 2*time.Hour, time.Hour)
+	if err := d.GetSession(&s); err != nil {
+		t.Fatal(err)
+	}
+	if since := time.Since(s.CreatedAt); since < 2*time.Hour || since > 2*time.Hour+time.Minute {
+		t.Errorf("unexpected CreatedAt: %v", s.CreatedAt)
+	}
+	if since := time.Since(s.LastSeenAt); since < time.Hour || since > time.Hour+time.Minute {
+		t.Errorf("unexpected LastSeenAt: %v", s.LastSeenAt)
+	}
+
+	createdAt := s.CreatedAt
+	if err := d.TouchSession(&s); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.GetSession(&s); err != nil {
+		t.Fatal(err)
+	}
+	if !s.CreatedAt.Equal(createdAt) {
+		t.Errorf("TouchSession changed CreatedAt from %v to %v", createdAt, s.CreatedAt)
+	}
+	if since := time.Since(s.LastSeenAt); since > time.Minute {
+		t.Errorf("LastSeenAt not renewed by TouchSession: %v", s.LastSeenAt)
+	}
+}
+
+func TestRemoveExpiredSessions(t *testing.T) {
+	d := testStorage(t)
+
+	var (
+		absoluteExpired = Session{GithubUserID: 1, SessionID: [32]byte{1}}
+		idleExpired     = Session{GithubUserID: 1, SessionID: [32]byte{2}}
+		valid           = Session{GithubUserID: 2, SessionID: [32]byte{1}}
+	)
+	for _, v := range []*Session{&absoluteExpired, &idleExpired, &valid} {
+		if err := d.StoreSession(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backdateSession(t, d, &absoluteExpired, 31*24*time.Hour, 0)
+	backdateSession(t, d, &idleExpired, 8*24*time.Hour, 8*24*time.Hour)
+	backdateSession(t, d, &valid, 29*24*time.Hour, 6*24*time.Hour)
+
+	now := time.Now()
+	n, err := d.RemoveExpiredSessions(now.Add(-30*24*time.Hour), now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("RemoveExpiredSessions removed %v sessions, want: 2", n)
+	}
+
+	for _, v := range []*Session{&absoluteExpired, &idleExpired} {
+		if err := d.GetSession(v); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetSession(%x) of an expired session = %v, want: %v", v.SessionID[:1], err, ErrNotFound)
+		}
+	}
+	if err := d.GetSession(&valid); err != nil {
+		t.Errorf("GetSession of a valid session = %v", err)
+	}
+}
+
+func TestUserSessions(t *testing.T) {
+	d := testStorage(t)
+
+	sessions := []Session{
+		{GithubUserID: 1, SessionID: [32]byte{1}, UserAgent: "first", IP: "192.0.2.0"},
+		{GithubUserID: 1, SessionID: [32]byte{2}, UserAgent: "second", IP: "198.51.100.0"},
+		{GithubUserID: 2, SessionID: [32]byte{3}, UserAgent: "other user"},
+	}
+	for i := range sessions {
+		if err := d.StoreSession(&sessions[i]); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.GetSession(&sessions[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backdateSession(t, d, &sessions[0], time.Hour, time.Hour)
+
+	got, err := d.GetUserSessions(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ID != sessions[1].ID || got[1].ID != sessions[0].ID ||
+		got[0].UserAgent != "second" || got[0].IP != "198.51.100.0" || got[0].SessionID != [32]byte{} {
+		t.Fatalf("unexpected sessions: %#v", got)
+	}
+
+	if err := d.RemoveSessionByID(1, sessions[2].ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RemoveSessionByID of a session of another user = %v, want: %v", err, ErrNotFound)
+	}
+	if err := d.RemoveSessionByID(1, sessions[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.GetSession(&sessions[0]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetSession of a removed session = %v, want: %v", err, ErrNotFound)
+	}
+
+	if err := d.RemoveUserSessions(1); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := d.GetUserSessions(1); err != nil || len(got) != 0 {
+		t.Errorf("GetUserSessions after RemoveUserSessions = %v, %v, want no sessions", got, err)
+	}
+	if err := d.GetSession(&sessions[2]); err != nil {
+		t.Errorf("GetSession of a session of another user = %v", err)
+	}
+}
@@ -14,7 +14,10 @@ var (
 	// Parse and execute templates, they do not change over time.
 	indexContent    = mustParseAndExec("tmpls/layout.html", "tmpls/index.html")
 	mySharesContent = mustParseAndExec("tmpls/layout.html", "tmpls/my-shares.html")
-	shareContent    = mustParseAndExec("tmpls/layout.html", "tmpls/share.html")
+	sessionsContent = mustParseAndExec("tmpls/layout.html", "tmpls/sessions.html")
+
+	// Share page is executed per request, it contains share-specific metadata.
+	shareTemplate = template.Must(template.ParseFS(tmpls, "tmpls/layout.html", "tmpls/share.html"))
 )
 
 func mustParseAndExec(templates ...string) []byte {
@@ -36,7 +39,22 @@ func MyShares(w io.Writer) error {
 	return err
 }
 
-func Share(w io.Writer) error {
-	_, err := w.Write(shareContent)
+func Sessions(w io.Writer) error {
+	_, err := w.Write(sessionsContent)
 	return err
 }
+
+// ShareData is the share-specific content of the share page,
+// used for the Open Graph and Twitter card metadata.
+type ShareData struct {
+	Title       string
+	Description string
+
+	// URL and ImageURL are absolute URLs, empty when unknown.
+	URL      string
+	ImageURL string
+}
+
+func Share(w io.Writer, data *ShareData) error {
+	return shareTemplate.Execute(w, data)
+}